@@ -2,18 +2,25 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
+	"net"
 	"net/http"
-	"net/url"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
 )
 
 //go:embed templates/captcha.html
@@ -22,11 +29,10 @@ var captchaHTML string
 //go:embed static/*
 var staticFiles embed.FS
 
+// Default listener of the captcha web interface
 const (
-	CaptchaBaseURL   = "https://myip.ms"
-	CaptchaTargetURL = CaptchaBaseURL + "/ajax_table/sites/1"
-	CaptchaFinalURL  = CaptchaBaseURL + "/browse/sites/1"
-	WebServerPort    = ":5050"
+	DefaultCaptchaBind = "127.0.0.1"
+	DefaultCaptchaPort = 5050
 )
 
 // CaptchaData holds the current captcha information
@@ -36,12 +42,70 @@ type CaptchaData struct {
 	mu       sync.RWMutex
 }
 
+var (
+	errCaptchaRequired = errors.New("captcha text is required")
+	errNoCaptchaToken  = errors.New("no captcha token available")
+)
+
+// Submit validates an answer to the current captcha. When the site rejects
+// it, the next captcha becomes current and myipms.ErrCaptchaRejected is returned.
+func (cd *CaptchaData) Submit(ctx context.Context, client *myipms.Client, answer string) error {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return errCaptchaRequired
+	}
+
+	token := cd.current()
+	if token == "" {
+		return errNoCaptchaToken
+	}
+
+	next, err := client.SubmitCaptcha(ctx, &myipms.Captcha{Token: token}, answer)
+	switch {
+	case err == nil:
+		// A solved captcha cannot be answered again
+		cd.Set("", "")
+	case errors.Is(err, myipms.ErrCaptchaRejected) && next != nil:
+		cd.Set(next.Token, next.ImageURL)
+	}
+	return err
+}
+
+// current returns the token of the current captcha
+func (cd *CaptchaData) current() string {
+	cd.mu.RLock()
+	defer cd.mu.RUnlock()
+	return cd.Token
+}
+
+// Set makes the given captcha current
+func (cd *CaptchaData) Set(token, imageURL string) {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	cd.Token = token
+	cd.ImageURL = imageURL
+}
+
 // CaptchaServer manages the web interface for captcha solving
 type CaptchaServer struct {
 	server      *http.Server
+	cancel      context.CancelFunc
+	listener    net.Listener
+	dashboard   *Dashboard
+	addr        string
 	captchaData *CaptchaData
 	result      chan string
-	client      *HTTPClient
+	client      *myipms.Client
+
+	// token is the access token every request must carry. It is created on
+	// Start and lasts as long as the server, so a dashboard can be reloaded.
+	// captchaToken is also required to see and answer the pending captcha; it
+	// is created for each captcha and revoked once the captcha is solved.
+	// Empty means none is valid.
+	token        string
+	captchaToken string
+	tokenMu      sync.RWMutex
 }
 
 // openBrowser opens the default web browser to the specified URL
@@ -62,17 +126,28 @@ func openBrowser(url string) error {
 	return exec.Command(cmd, args...).Start()
 }
 
-// NewCaptchaServer creates a new captcha server instance
-func NewCaptchaServer(client *HTTPClient) *CaptchaServer {
+// NewCaptchaServer creates a new captcha server instance listening on
+// bind:port. Port 0 picks any free port.
+func NewCaptchaServer(client *myipms.Client, bind string, port int) *CaptchaServer {
 	return &CaptchaServer{
+		addr:        net.JoinHostPort(bind, strconv.Itoa(port)),
 		captchaData: &CaptchaData{},
 		result:      make(chan string, 1),
 		client:      client,
 	}
 }
 
-// Start starts the web server for captcha solving
+// Start opens the listener and serves the captcha interface in the background.
+// Every request must carry the random token included in URL.
 func (cs *CaptchaServer) Start() error {
+	token, err := newAccessToken()
+	if err != nil {
+		return err
+	}
+	cs.tokenMu.Lock()
+	cs.token = token
+	cs.tokenMu.Unlock()
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/", cs.handleIndex)
@@ -83,22 +158,131 @@ func (cs *CaptchaServer) Start() error {
 	}
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
 
-	mux.HandleFunc("/captcha-image", cs.handleCaptchaImage)
+	mux.Handle("/captcha-image", cs.requireCaptchaToken(http.HandlerFunc(cs.handleCaptchaImage)))
+
+	mux.Handle("/submit-captcha", cs.requireCaptchaToken(http.HandlerFunc(cs.handleSubmitCaptcha)))
 
-	mux.HandleFunc("/submit-captcha", cs.handleSubmitCaptcha)
+	mux.Handle("/refresh-captcha", cs.requireCaptchaToken(http.HandlerFunc(cs.handleRefreshCaptcha)))
 
-	mux.HandleFunc("/refresh-captcha", cs.handleRefreshCaptcha)
+	if cs.dashboard != nil {
+		mux.HandleFunc("/events", cs.dashboard.handleEvents)
+	}
+
+	listener, err := net.Listen("tcp", cs.addr)
+	if err != nil {
+		return fmt.Errorf("captcha server could not listen on %s: %v (use -captcha-port=0 to pick a free port)", cs.addr, err)
+	}
+	cs.listener = listener
 
+	// Cancelling the base context ends long-lived event streams on Stop
+	baseCtx, cancel := context.WithCancel(context.Background())
+	cs.cancel = cancel
 	cs.server = &http.Server{
-		Addr:    WebServerPort,
-		Handler: mux,
+		Handler:     cs.requireToken(mux),
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
+	go func() {
+		if err := cs.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error running captcha server: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// URL returns the address of the interface, including the access token
+func (cs *CaptchaServer) URL() string {
+	host, port, _ := net.SplitHostPort(cs.listener.Addr().String())
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "localhost"
 	}
+	return "http://" + net.JoinHostPort(host, port) + "/?token=" + cs.accessToken()
+}
+
+// accessToken returns the token requests must carry, or "" when none is valid
+func (cs *CaptchaServer) accessToken() string {
+	cs.tokenMu.RLock()
+	defer cs.tokenMu.RUnlock()
+	return cs.token
+}
 
-	if err := openBrowser("http://localhost" + WebServerPort); err != nil {
-		fmt.Printf("Could not open browser automatically. Please manually navigate to: http://localhost%s\n", WebServerPort)
+// captchaAccessToken returns the token of the pending captcha, or "" when
+// none is valid
+func (cs *CaptchaServer) captchaAccessToken() string {
+	cs.tokenMu.RLock()
+	defer cs.tokenMu.RUnlock()
+	return cs.captchaToken
+}
+
+// newAccessToken returns a new random token
+func newAccessToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate access token: %v", err)
 	}
+	return hex.EncodeToString(token), nil
+}
+
+// newCaptchaToken creates the token of a new captcha, so answers meant for
+// an earlier one are rejected
+func (cs *CaptchaServer) newCaptchaToken() error {
+	token, err := newAccessToken()
+	if err != nil {
+		return err
+	}
+
+	cs.tokenMu.Lock()
+	defer cs.tokenMu.Unlock()
+	cs.captchaToken = token
+	return nil
+}
+
+// revokeCaptchaToken rejects every request for the captcha from now on,
+// until newCaptchaToken
+func (cs *CaptchaServer) revokeCaptchaToken() {
+	cs.tokenMu.Lock()
+	defer cs.tokenMu.Unlock()
+	cs.captchaToken = ""
+}
 
-	return cs.server.ListenAndServe()
+// revokeToken rejects every request from now on. Only the standalone
+// captcha page uses it, since it exists for one captcha; the dashboard keeps
+// its token for the whole scrape.
+func (cs *CaptchaServer) revokeToken() {
+	cs.tokenMu.Lock()
+	defer cs.tokenMu.Unlock()
+	cs.token = ""
+}
+
+// requireToken rejects requests that do not carry the access token, either as
+// the token query parameter or the X-Captcha-Token header
+func (cs *CaptchaServer) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			token = r.Header.Get("X-Captcha-Token")
+		}
+		current := cs.accessToken()
+		if current == "" || subtle.ConstantTimeCompare([]byte(token), []byte(current)) != 1 {
+			http.Error(w, "Forbidden: missing or invalid access token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireCaptchaToken rejects requests that do not carry the token of the
+// pending captcha as the captcha_token query parameter
+func (cs *CaptchaServer) requireCaptchaToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("captcha_token")
+		current := cs.captchaAccessToken()
+		if current == "" || subtle.ConstantTimeCompare([]byte(token), []byte(current)) != 1 {
+			http.Error(w, "Forbidden: missing or expired captcha token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // Stop stops the web server
@@ -107,6 +291,8 @@ func (cs *CaptchaServer) Stop() error {
 		return nil
 	}
 
+	cs.cancel()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -123,7 +309,12 @@ func (cs *CaptchaServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := tmpl.Execute(w, nil); err != nil {
+	data := struct {
+		Token        string
+		CaptchaToken string
+		Dashboard    bool
+	}{cs.accessToken(), cs.captchaAccessToken(), cs.dashboard != nil}
+	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, "Error executing template: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -140,7 +331,7 @@ func (cs *CaptchaServer) handleCaptchaImage(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	resp, err := cs.client.get(imageURL)
+	resp, err := cs.client.Get(r.Context(), imageURL)
 	if err != nil {
 		http.Error(w, "Failed to fetch captcha image", http.StatusInternalServerError)
 		return
@@ -175,47 +366,18 @@ func (cs *CaptchaServer) handleSubmitCaptcha(w http.ResponseWriter, r *http.Requ
 	}
 
 	captchaText := strings.TrimSpace(r.FormValue("captcha"))
-	if captchaText == "" {
+
+	err := cs.captchaData.Submit(r.Context(), cs.client, captchaText)
+	switch {
+	case errors.Is(err, errCaptchaRequired):
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(`{"success": false, "message": "Captcha text is required"}`))
-		return
-	}
-
-	captchaToken := cs.GetCaptchaToken()
-	if captchaToken == "" {
+	case errors.Is(err, errNoCaptchaToken):
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(`{"success": false, "message": "No captcha token available"}`))
-		return
-	}
-
-	finalData := url.Values{
-		"x":                  {"0"},
-		"y":                  {"0"},
-		"g_recaptcha_loaded": {"no"},
-		"captcha_token":      {captchaToken},
-		"p_captcha_response": {captchaText},
-	}
-
-	resp, err := cs.client.post(CaptchaFinalURL, finalData)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"success": false, "message": "Error validating captcha: ` + err.Error() + `"}`))
-		return
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"success": false, "message": "Error reading validation response"}`))
-		return
-	}
-
-	if !strings.Contains(string(body), "captcha_token") {
+	case err == nil:
 		select {
 		case cs.result <- captchaText:
 			w.Header().Set("Content-Type", "application/json")
@@ -225,45 +387,32 @@ func (cs *CaptchaServer) handleSubmitCaptcha(w http.ResponseWriter, r *http.Requ
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(`{"success": false, "message": "Server is not ready to accept captcha"}`))
 		}
-	} else {
-		newCaptchaToken := extractCaptchaToken(string(body))
-		newCaptchaURL := extractCaptchaURL(string(body))
-
-		if newCaptchaToken != "" && newCaptchaURL != "" {
-			cs.SetCaptchaData(newCaptchaToken, newCaptchaURL)
-		}
-
+	case errors.Is(err, myipms.ErrCaptchaRejected):
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(`{"success": false, "message": "Incorrect captcha. Please try again with the new image."}`))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"success": false, "message": "` + template.JSEscapeString(err.Error()) + `"}`))
 	}
 }
 
-// WaitForCaptcha waits for user input from the web interface
-func (cs *CaptchaServer) WaitForCaptcha() (string, error) {
+// WaitForCaptcha waits for user input from the web interface or for ctx to be cancelled
+func (cs *CaptchaServer) WaitForCaptcha(ctx context.Context) (string, error) {
 	select {
 	case result := <-cs.result:
 		return result, nil
 	case <-time.After(5 * time.Minute):
 		return "", fmt.Errorf("captcha input timeout")
+	case <-ctx.Done():
+		return "", ctx.Err()
 	}
 }
 
 // SetCaptchaData updates the current captcha data
 func (cs *CaptchaServer) SetCaptchaData(token, imageURL string) {
-	cs.captchaData.mu.Lock()
-	defer cs.captchaData.mu.Unlock()
-
-	cs.captchaData.Token = token
-	cs.captchaData.ImageURL = imageURL
-}
-
-// GetCaptchaToken returns the current captcha token
-func (cs *CaptchaServer) GetCaptchaToken() string {
-	cs.captchaData.mu.RLock()
-	defer cs.captchaData.mu.RUnlock()
-
-	return cs.captchaData.Token
+	cs.captchaData.Set(token, imageURL)
 }
 
 // handleRefreshCaptcha handles requests to refresh captcha data
@@ -273,88 +422,65 @@ func (cs *CaptchaServer) handleRefreshCaptcha(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	postData := url.Values{
-		"x":                    {"150"},
-		"y":                    {"58"},
-		"g_recaptcha_loaded":   {"no"},
-		"captcha_token":        {""},
-		"g_recaptcha_response": {""},
-	}
-
-	resp, err := cs.client.post(CaptchaTargetURL, postData)
+	captcha, err := cs.client.RequestCaptcha(r.Context())
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(`{"success": false, "message": "Error fetching new captcha"}`))
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"success": false, "message": "Error reading captcha response"}`))
-		return
-	}
+	cs.SetCaptchaData(captcha.Token, captcha.ImageURL)
 
-	html := string(body)
-	captchaToken := extractCaptchaToken(html)
-	captchaURL := extractCaptchaURL(html)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"success": true, "message": "Captcha refreshed successfully"}`))
+}
 
-	if captchaURL == "" || captchaToken == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"success": false, "message": "No captcha data found in response"}`))
-		return
-	}
+// captchaModes are the accepted values of -captcha
+var captchaModes = []string{"web", "terminal", "blocks", "sixel"}
 
-	cs.SetCaptchaData(captchaToken, captchaURL)
+// isCaptchaMode reports whether mode is a supported captcha mode
+func isCaptchaMode(mode string) bool {
+	for _, m := range captchaModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"success": true, "message": "Captcha refreshed successfully"}`))
+// solveCaptcha runs the captcha solver selected by -captcha, using the
+// dashboard for web captchas when one is running. It gives up as soon as ctx
+// is cancelled.
+func solveCaptcha(ctx context.Context, client *myipms.Client, c *Config, dashboard *Dashboard) error {
+	if c.Captcha == "web" && dashboard != nil {
+		return dashboard.SolveCaptcha(ctx)
+	}
+	if c.Captcha == "web" {
+		return solveCaptchaInBrowser(ctx, client, c.CaptchaBind, c.CaptchaPort)
+	}
+	return solveCaptchaInTerminal(ctx, client, c.Captcha, c.CaptchaImage)
 }
 
-// solveCaptcha handles the complete captcha solving process with web interface
-func solveCaptcha(client *HTTPClient) error {
+// solveCaptchaInBrowser handles the complete captcha solving process with web interface
+func solveCaptchaInBrowser(ctx context.Context, client *myipms.Client, bind string, port int) error {
 	fmt.Println("Starting captcha solving process...")
 
-	captchaServer := NewCaptchaServer(client)
+	captchaServer := NewCaptchaServer(client, bind, port)
 
-	postData := url.Values{
-		"x":                    {"150"},
-		"y":                    {"58"},
-		"g_recaptcha_loaded":   {"no"},
-		"captcha_token":        {""},
-		"g_recaptcha_response": {""},
+	captcha, err := client.RequestCaptcha(ctx)
+	if err != nil {
+		return err
 	}
 
-	resp, err := client.post(CaptchaTargetURL, postData)
-	if err != nil {
-		return fmt.Errorf("error making initial request: %v", err)
+	captchaServer.SetCaptchaData(captcha.Token, captcha.ImageURL)
+	if err := captchaServer.newCaptchaToken(); err != nil {
+		return err
+	}
+
+	if err := captchaServer.Start(); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
-	
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("error reading response: %v", err)
-	}
-	
-	html := string(body)
-	captchaToken := extractCaptchaToken(html)
-	captchaURL := extractCaptchaURL(html)
-	
-	if captchaURL == "" || captchaToken == "" {
-		return fmt.Errorf("no captcha image URL or token found")
-	}
-	
-	captchaServer.SetCaptchaData(captchaToken, captchaURL)
-	
-	go func() {
-		if err := captchaServer.Start(); err != nil && err != http.ErrServerClosed {
-			fmt.Printf("Error starting captcha server: %v\n", err)
-		}
-	}()
 
 	defer func() {
 		if err := captchaServer.Stop(); err != nil {
@@ -363,9 +489,15 @@ func solveCaptcha(client *HTTPClient) error {
 	}()
 
 	fmt.Println("Captcha image is ready! Please solve it in your web browser.")
-	fmt.Printf("If the browser didn't open automatically, navigate to: http://localhost%s\n", WebServerPort)
-	
-	captchaResponse, err := captchaServer.WaitForCaptcha()
+	if err := openBrowser(captchaServer.URL()); err != nil {
+		fmt.Println("Could not open browser automatically.")
+	}
+	fmt.Printf("If the browser didn't open automatically, navigate to: %s\n", captchaServer.URL())
+
+	captchaResponse, err := captchaServer.WaitForCaptcha(ctx)
+	// The page was for this captcha only
+	captchaServer.revokeCaptchaToken()
+	captchaServer.revokeToken()
 	if err != nil {
 		return fmt.Errorf("error getting captcha response: %v", err)
 	}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// solveCaptchaInTerminal shows the captcha in the terminal, or saves it to
+// imagePath, and reads answers from stdin until one is accepted. An empty
+// answer fetches a new captcha.
+func solveCaptchaInTerminal(ctx context.Context, client *myipms.Client, mode, imagePath string) error {
+	if mode == "terminal" {
+		mode = "blocks"
+		if sixelSupported() {
+			mode = "sixel"
+		}
+	}
+	return promptCaptcha(ctx, client, mode, imagePath, stdinLines(), os.Stdout)
+}
+
+// promptCaptcha runs the terminal solver in the given drawing mode, reading
+// answers from lines and writing the image and prompts to out
+func promptCaptcha(ctx context.Context, client *myipms.Client, mode, imagePath string, lines <-chan string, out io.Writer) error {
+	fmt.Fprintln(out, "Starting captcha solving process...")
+
+	data := &CaptchaData{}
+	if err := refreshTerminalCaptcha(ctx, client, data); err != nil {
+		return err
+	}
+
+	for {
+		if err := showTerminalCaptcha(ctx, client, data, mode, imagePath, out); err != nil {
+			return err
+		}
+
+		fmt.Fprint(out, "Enter captcha text (empty for a new image): ")
+		var answer string
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return fmt.Errorf("no captcha response provided")
+			}
+			answer = strings.TrimSpace(line)
+		case <-ctx.Done():
+			fmt.Fprintln(out)
+			return ctx.Err()
+		}
+
+		if answer == "" {
+			if err := refreshTerminalCaptcha(ctx, client, data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		token := data.current()
+		err := data.Submit(ctx, client, answer)
+		switch {
+		case err == nil:
+			fmt.Fprintln(out, "Captcha solving process completed successfully!")
+			return nil
+		case errors.Is(err, myipms.ErrCaptchaRejected):
+			fmt.Fprintln(out, "Incorrect captcha. Please try again with the new image.")
+			// The site did not send a new captcha, and the rejected token is used up
+			if data.current() == token {
+				if err := refreshTerminalCaptcha(ctx, client, data); err != nil {
+					return err
+				}
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// refreshTerminalCaptcha requests a new captcha and makes it current
+func refreshTerminalCaptcha(ctx context.Context, client *myipms.Client, data *CaptchaData) error {
+	captcha, err := client.RequestCaptcha(ctx)
+	if err != nil {
+		return err
+	}
+	data.Set(captcha.Token, captcha.ImageURL)
+	return nil
+}
+
+var (
+	stdinOnce sync.Once
+	stdin     <-chan string
+)
+
+// stdinLines returns a channel carrying the lines read from stdin, closed at
+// EOF. A single goroutine reads stdin for the whole run, so a prompt abandoned
+// on ctx never swallows the answer to the next one.
+func stdinLines() <-chan string {
+	stdinOnce.Do(func() {
+		stdin = readLines(os.Stdin)
+	})
+	return stdin
+}
+
+// readLines returns a channel carrying the lines read from r, closed at EOF
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+// showTerminalCaptcha downloads the current captcha image and draws it to out
+// in the given mode, or saves it when imagePath is set
+func showTerminalCaptcha(ctx context.Context, client *myipms.Client, data *CaptchaData, mode, imagePath string, out io.Writer) error {
+	data.mu.RLock()
+	imageURL := data.ImageURL
+	data.mu.RUnlock()
+
+	resp, err := client.Get(ctx, imageURL)
+	if err != nil {
+		return fmt.Errorf("error fetching captcha image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error fetching captcha image: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error fetching captcha image: status %d", resp.StatusCode)
+	}
+
+	if imagePath != "" {
+		if err := os.WriteFile(imagePath, body, 0644); err != nil {
+			return fmt.Errorf("error saving captcha image: %v", err)
+		}
+		fmt.Fprintf(out, "Captcha image saved to %s\n", imagePath)
+		return nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error decoding captcha image: %v", err)
+	}
+
+	if mode == "sixel" {
+		writeSixel(out, img)
+	} else {
+		writeHalfBlocks(out, img, terminalWidth())
+	}
+	return nil
+}
+
+// sixelSupported guesses from the environment whether the terminal draws sixel graphics
+func sixelSupported() bool {
+	term := os.Getenv("TERM")
+	if strings.Contains(term, "sixel") {
+		return true
+	}
+	for _, prefix := range []string{"mlterm", "foot", "yaft", "contour"} {
+		if strings.HasPrefix(term, prefix) {
+			return true
+		}
+	}
+
+	switch os.Getenv("TERM_PROGRAM") {
+	case "WezTerm", "mintty", "iTerm.app":
+		return true
+	}
+	return false
+}
+
+// terminalWidth returns the terminal width from $COLUMNS, or 80
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// writeHalfBlocks draws img with "▀" characters in 24-bit color, two pixel
+// rows per line, scaled down to fit maxWidth columns
+func writeHalfBlocks(w io.Writer, img image.Image, maxWidth int) {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	if width > maxWidth-1 {
+		width = maxWidth - 1
+	}
+	if width < 1 {
+		return
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 2 {
+		height = 2
+	}
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	for y := 0; y < height; y += 2 {
+		for x := 0; x < width; x++ {
+			top := averageColor(img, x, y, width, height)
+			bottom := averageColor(img, x, y+1, width, height)
+			fmt.Fprintf(bw, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+				top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		bw.WriteString("\x1b[0m\n")
+	}
+}
+
+// averageColor returns the mean color of the source pixels covered by cell
+// (x, y) when img is scaled to width x height, composited onto white
+func averageColor(img image.Image, x, y, width, height int) color.RGBA {
+	bounds := img.Bounds()
+	if y >= height {
+		return color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	}
+
+	x0 := bounds.Min.X + x*bounds.Dx()/width
+	x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+	y0 := bounds.Min.Y + y*bounds.Dy()/height
+	y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+	if x1 <= x0 {
+		x1 = x0 + 1
+	}
+	if y1 <= y0 {
+		y1 = y0 + 1
+	}
+
+	var r, g, b, n uint32
+	for sy := y0; sy < y1; sy++ {
+		for sx := x0; sx < x1; sx++ {
+			cr, cg, cb, ca := img.At(sx, sy).RGBA()
+			white := 0xffff - ca
+			r += (cr + white) >> 8
+			g += (cg + white) >> 8
+			b += (cb + white) >> 8
+			n++
+		}
+	}
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 0xff}
+}
+
+// writeSixel draws img as DEC sixel graphics using the web-safe palette
+func writeSixel(w io.Writer, img image.Image) {
+	bounds := img.Bounds()
+	paletted := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	width, height := paletted.Bounds().Dx(), paletted.Bounds().Dy()
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	fmt.Fprintf(bw, "\x1bPq\"1;1;%d;%d", width, height)
+	for i, c := range paletted.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	for band := 0; band < height; band += 6 {
+		used := make(map[uint8]bool)
+		for y := band; y < band+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				used[paletted.ColorIndexAt(x, y)] = true
+			}
+		}
+
+		first := true
+		for idx := 0; idx < len(paletted.Palette); idx++ {
+			if !used[uint8(idx)] {
+				continue
+			}
+			if !first {
+				bw.WriteByte('$')
+			}
+			first = false
+
+			fmt.Fprintf(bw, "#%d", idx)
+			run, last := 0, byte(0)
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < height; dy++ {
+					if paletted.ColorIndexAt(x, band+dy) == uint8(idx) {
+						bits |= 1 << dy
+					}
+				}
+				ch := 63 + bits
+				if run > 0 && ch != last {
+					writeSixelRun(bw, last, run)
+					run = 0
+				}
+				last = ch
+				run++
+			}
+			writeSixelRun(bw, last, run)
+		}
+		bw.WriteByte('-')
+	}
+
+	bw.WriteString("\x1b\\\n")
+}
+
+// writeSixelRun writes n repetitions of a sixel character, run-length encoded
+func writeSixelRun(w *bufio.Writer, ch byte, n int) {
+	if n > 3 {
+		fmt.Fprintf(w, "!%d%c", n, ch)
+		return
+	}
+	for i := 0; i < n; i++ {
+		w.WriteByte(ch)
+	}
+}
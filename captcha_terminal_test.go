@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+	"github.com/ayanrajpoot10/myipms-scraper/myipms/myipmstest"
+)
+
+// testImage is 2x2: red and green on top, blue and transparent below
+func testImage() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.NRGBA{R: 0xff, A: 0xff})
+	img.Set(1, 0, color.NRGBA{G: 0xff, A: 0xff})
+	img.Set(0, 1, color.NRGBA{B: 0xff, A: 0xff})
+	img.Set(1, 1, color.NRGBA{})
+	return img
+}
+
+func TestWriteHalfBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	writeHalfBlocks(&buf, testImage(), 80)
+
+	// One line: each cell's foreground is the top pixel, its background the bottom one
+	want := "\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀" +
+		"\x1b[38;2;0;255;0m\x1b[48;2;255;255;255m▀" +
+		"\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeHalfBlocks() = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	writeHalfBlocks(&buf, testImage(), 1)
+	if buf.Len() != 0 {
+		t.Errorf("writeHalfBlocks() with no room = %q, want nothing", buf.String())
+	}
+}
+
+func TestAverageColor(t *testing.T) {
+	half := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	half.Set(0, 0, color.NRGBA{A: 0x80})
+
+	tests := []struct {
+		name          string
+		img           image.Image
+		x, y          int
+		width, height int
+		want          color.RGBA
+	}{
+		{name: "opaque pixel", img: testImage(), x: 1, y: 0, width: 2, height: 2, want: color.RGBA{G: 0xff, A: 0xff}},
+		{name: "transparent pixel is white", img: testImage(), x: 1, y: 1, width: 2, height: 2, want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{name: "half transparent black is grey", img: half, width: 1, height: 1, want: color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}},
+		{name: "scaled down to one cell", img: testImage(), width: 1, height: 1, want: color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}},
+		{name: "row past the image is white", img: testImage(), y: 2, width: 2, height: 2, want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := averageColor(tt.img, tt.x, tt.y, tt.width, tt.height); got != tt.want {
+				t.Errorf("averageColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSixel(t *testing.T) {
+	white := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			white.Set(x, y, color.White)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeSixel(&buf, white)
+	out := buf.String()
+
+	header := "\x1bPq\"1;1;2;2"
+	if !strings.HasPrefix(out, header) {
+		t.Fatalf("writeSixel() does not start with %q", header)
+	}
+
+	// The web-safe palette in percent, from black to white
+	palette := out[len(header) : strings.Index(out, "#215;2;100;100;100")+len("#215;2;100;100;100")]
+	for _, entry := range []string{"#0;2;0;0;0", "#1;2;0;0;20", "#6;2;0;20;0", "#36;2;20;0;0"} {
+		if !strings.Contains(palette, entry) {
+			t.Errorf("palette is missing %q", entry)
+		}
+	}
+	if n := strings.Count(palette, ";2;"); n != 216 {
+		t.Errorf("palette has %d colors, want 216", n)
+	}
+
+	// One band of white, both pixel rows set in each column
+	if body := out[len(header)+len(palette):]; body != "#215BB-\x1b\\\n" {
+		t.Errorf("sixel data = %q, want %q", body, "#215BB-\x1b\\\n")
+	}
+}
+
+func TestPromptCaptcha(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// shown is the number of images shown and requested the number of
+		// captchas requested from the site
+		shown, requested int
+		rejected         int
+		wantErr          string
+	}{
+		{name: "right answer", input: myipmstest.DefaultCaptchaAnswer + "\n", shown: 1, requested: 1},
+		// The rejection carries the next captcha
+		{name: "wrong answer", input: "0000\n" + myipmstest.DefaultCaptchaAnswer + "\n", shown: 2, requested: 1, rejected: 1},
+		{name: "empty line", input: "\n" + myipmstest.DefaultCaptchaAnswer + "\n", shown: 2, requested: 2},
+		{name: "wrong then empty", input: "0000\n  \n" + myipmstest.DefaultCaptchaAnswer + "\n", shown: 3, requested: 2, rejected: 1},
+		{name: "no answer", input: "", shown: 1, requested: 1, wantErr: "no captcha response provided"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myipmstest.NewServer(myipmstest.Options{})
+			defer srv.Close()
+			srv.RequireVerification()
+			client, err := myipms.NewClient(myipms.ClientOptions{BaseURL: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			var out bytes.Buffer
+			imagePath := filepath.Join(t.TempDir(), "captcha.png")
+			err = promptCaptcha(ctx, client, "blocks", imagePath, readLines(strings.NewReader(tt.input)), &out)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("promptCaptcha() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("promptCaptcha: %v\n%s", err, out.String())
+			}
+
+			if got := strings.Count(out.String(), "Captcha image saved to"); got != tt.shown {
+				t.Errorf("shown %d images, want %d", got, tt.shown)
+			}
+			if got := strings.Count(out.String(), "Incorrect captcha"); got != tt.rejected {
+				t.Errorf("%d rejections reported, want %d", got, tt.rejected)
+			}
+			requested := 0
+			for _, path := range srv.Paths() {
+				if path == "/ajax_table/sites/1" {
+					requested++
+				}
+			}
+			if requested != tt.requested {
+				t.Errorf("requested %d captchas, want %d", requested, tt.requested)
+			}
+		})
+	}
+}
+
+func TestPromptCaptchaDraws(t *testing.T) {
+	for mode, want := range map[string]string{"blocks": "▀", "sixel": "\x1bPq"} {
+		t.Run(mode, func(t *testing.T) {
+			srv := myipmstest.NewServer(myipmstest.Options{})
+			defer srv.Close()
+			srv.RequireVerification()
+			client, err := myipms.NewClient(myipms.ClientOptions{BaseURL: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			lines := readLines(strings.NewReader(myipmstest.DefaultCaptchaAnswer + "\n"))
+			if err := promptCaptcha(context.Background(), client, mode, "", lines, &out); err != nil {
+				t.Fatalf("promptCaptcha: %v", err)
+			}
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s output does not contain %q", mode, want)
+			}
+		})
+	}
+}
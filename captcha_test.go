@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+	"github.com/ayanrajpoot10/myipms-scraper/myipms/myipmstest"
+)
+
+func TestRequireToken(t *testing.T) {
+	cs := &CaptchaServer{token: "0123456789abcdef"}
+	srv := httptest.NewServer(cs.requireToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		want   int
+	}{
+		{name: "missing token", want: http.StatusForbidden},
+		{name: "wrong query token", query: "wrong", want: http.StatusForbidden},
+		{name: "wrong header token", header: "0123456789abcdee", want: http.StatusForbidden},
+		{name: "token prefix", query: "0123456789", want: http.StatusForbidden},
+		{name: "query token", query: cs.token, want: http.StatusOK},
+		{name: "header token", header: cs.token, want: http.StatusOK},
+		// A wrong query parameter is not rescued by the header
+		{name: "wrong query, right header", query: "wrong", header: cs.token, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := srv.URL + "/captcha-image"
+			if tt.query != "" {
+				target += "?token=" + url.QueryEscape(tt.query)
+			}
+			req, err := http.NewRequest("GET", target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Captcha-Token", tt.header)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptchaServerRequiresToken(t *testing.T) {
+	cs := NewCaptchaServer(nil, "127.0.0.1", 0)
+	if err := cs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Stop()
+
+	u, err := url.Parse(cs.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	withToken := u.String()
+	u.RawQuery = ""
+
+	for target, want := range map[string]int{
+		withToken:                                http.StatusOK,
+		u.String():                               http.StatusForbidden,
+		u.JoinPath("static/captcha.js").String(): http.StatusForbidden,
+	} {
+		resp, err := noKeepAlive.Get(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s = %d, want %d", target, resp.StatusCode, want)
+		}
+	}
+}
+
+// noKeepAlive does not leave connections open that would delay the servers' Stop
+var noKeepAlive = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+// status requests path from the captcha server with the given token
+func status(t *testing.T, cs *CaptchaServer, method, path, token, body string) int {
+	t.Helper()
+
+	u, err := url.Parse(cs.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(method, u.Scheme+"://"+u.Host+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Captcha-Token", token)
+
+	resp, err := noKeepAlive.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestRevokedTokenRejectsEverything(t *testing.T) {
+	cs := NewCaptchaServer(nil, "127.0.0.1", 0)
+	if err := cs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Stop()
+
+	token := cs.accessToken()
+	cs.revokeToken()
+	for _, tok := range []string{token, ""} {
+		if got := status(t, cs, "GET", "/", tok, ""); got != http.StatusForbidden {
+			t.Errorf("GET / with %q after revoking = %d, want 403", tok, got)
+		}
+	}
+}
+
+// firstEvent opens the dashboard's event stream with the given token and
+// returns the response status and the first event's progress
+func firstEvent(t *testing.T, cs *CaptchaServer, token string) (int, Progress) {
+	t.Helper()
+
+	u, err := url.Parse(cs.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := noKeepAlive.Get(u.Scheme + "://" + u.Host + "/events?token=" + url.QueryEscape(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var progress Progress
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, progress
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading event: %v", err)
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &progress); err != nil {
+		t.Fatalf("decoding event %q: %v", line, err)
+	}
+	return resp.StatusCode, progress
+}
+
+func TestDashboardSurvivesCaptcha(t *testing.T) {
+	srv := myipmstest.NewServer(myipmstest.Options{})
+	defer srv.Close()
+	srv.RequireVerification()
+	client, err := myipms.NewClient(myipms.ClientOptions{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dashboard, err := startDashboard(client, "127.0.0.1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dashboard.Stop()
+	cs := dashboard.server
+	token := cs.accessToken()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	solved := make(chan error, 1)
+	go func() { solved <- dashboard.SolveCaptcha(ctx) }()
+
+	// Wait for the captcha to be shown
+	var captchaToken string
+	for captchaToken == "" {
+		select {
+		case err := <-solved:
+			t.Fatalf("SolveCaptcha returned early: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+		dashboard.mu.Lock()
+		if dashboard.progress.CaptchaPending {
+			captchaToken = cs.captchaAccessToken()
+		}
+		dashboard.mu.Unlock()
+	}
+
+	if got := status(t, cs, "GET", "/", token, ""); got != http.StatusOK {
+		t.Errorf("reloading the printed URL during the captcha = %d, want 200", got)
+	}
+	code, progress := firstEvent(t, cs, token)
+	if code != http.StatusOK || !progress.CaptchaPending || progress.CaptchaToken != captchaToken {
+		t.Errorf("event stream during the captcha = %d %+v, want 200 with the pending captcha's token", code, progress)
+	}
+	if got := status(t, cs, "POST", "/submit-captcha", token, "captcha="+myipmstest.DefaultCaptchaAnswer); got != http.StatusForbidden {
+		t.Errorf("submitting without the captcha's token = %d, want 403", got)
+	}
+
+	answer := "/submit-captcha?captcha_token=" + captchaToken
+	if got := status(t, cs, "POST", answer, token, "captcha="+myipmstest.DefaultCaptchaAnswer); got != http.StatusOK {
+		t.Fatalf("submitting with the captcha's token = %d, want 200", got)
+	}
+	if err := <-solved; err != nil {
+		t.Fatalf("SolveCaptcha: %v", err)
+	}
+
+	// A reload, a second tab or a reconnecting event stream still get in
+	if got := status(t, cs, "GET", "/", token, ""); got != http.StatusOK {
+		t.Errorf("reloading the printed URL after the captcha = %d, want 200", got)
+	}
+	code, progress = firstEvent(t, cs, token)
+	if code != http.StatusOK || progress.CaptchaPending || progress.CaptchaToken != "" {
+		t.Errorf("event stream after the captcha = %d %+v, want 200 with no pending captcha", code, progress)
+	}
+
+	// The solved captcha cannot be answered or shown again
+	if got := status(t, cs, "POST", answer, token, "captcha="+myipmstest.DefaultCaptchaAnswer); got != http.StatusForbidden {
+		t.Errorf("submitting with the solved captcha's token = %d, want 403", got)
+	}
+	if got := status(t, cs, "GET", "/captcha-image?captcha_token="+captchaToken, token, ""); got != http.StatusForbidden {
+		t.Errorf("captcha image with the solved captcha's token = %d, want 403", got)
+	}
+}
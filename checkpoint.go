@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// Checkpoint records the progress of a scrape so it can be resumed later.
+// Each query of a multi-value filter has its own checkpoint, named by its tag,
+// which is kept marked Done once the query is complete.
+type Checkpoint struct {
+	Filter    myipms.Filter `json:"filter"`
+	Tag       string        `json:"tag,omitempty"`
+	NextPage  int           `json:"next_page"`
+	Total     int           `json:"total"`
+	Done      bool          `json:"done,omitempty"`
+	Output    string        `json:"output"`
+	UpdatedAt time.Time     `json:"updated_at"`
+
+	// saved reports whether the checkpoint is on disk for -resume to load
+	saved bool
+}
+
+// checkpointPath returns the checkpoint file path for an output file and the
+// tag of a multi-value filter query
+func checkpointPath(output, tag string) string {
+	if tag == "" {
+		return output + ".checkpoint"
+	}
+	h := fnv.New32a()
+	h.Write([]byte(tag))
+	return fmt.Sprintf("%s.%08x.checkpoint", output, h.Sum32())
+}
+
+// loadCheckpoint reads the checkpoint stored next to the output file
+func loadCheckpoint(output, tag string) (*Checkpoint, error) {
+	path := checkpointPath(output, tag)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no checkpoint found at %s", path)
+		}
+		return nil, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %v", path, err)
+	}
+
+	if cp.NextPage < 1 {
+		return nil, fmt.Errorf("invalid checkpoint %s: next page must be >= 1", path)
+	}
+
+	cp.saved = true
+	return &cp, nil
+}
+
+// save writes the checkpoint atomically next to its output file
+func (cp *Checkpoint) save() error {
+	cp.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := checkpointPath(cp.Output, cp.Tag)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	cp.saved = true
+	return nil
+}
+
+// finish records that the scrape is complete. A single query's checkpoint is
+// removed; a multi-value filter query's is kept marked Done, so resuming
+// skips it until every query is complete.
+func (cp *Checkpoint) finish() error {
+	if cp.Tag == "" {
+		return cp.remove()
+	}
+	cp.Done = true
+	return cp.save()
+}
+
+// remove deletes the checkpoint once there is nothing left to resume
+func (cp *Checkpoint) remove() error {
+	err := os.Remove(checkpointPath(cp.Output, cp.Tag))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	cp.saved = false
+	return nil
+}
+
+// resumeHint returns the flags that continue the scrape from page. -resume is
+// only offered once the checkpoint is saved, since it fails without one.
+func (cp *Checkpoint) resumeHint(page int) string {
+	if !cp.saved {
+		return fmt.Sprintf("-start %d", page)
+	}
+	return fmt.Sprintf("-resume (or -start %d)", page)
+}
+
+// resumeCheckpoint loads the checkpoint for the configured output and checks
+// that it was written for the same filter. Filters are compared by the request
+// they make, so display names that update-options has since completed do not
+// count as a change.
+func resumeCheckpoint(filter *myipms.Filter, c *Config, tag string) (*Checkpoint, error) {
+	cp, err := loadCheckpoint(c.Output, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if cp.Output != c.Output {
+		return nil, fmt.Errorf("checkpoint was written for output %q, not %q", cp.Output, c.Output)
+	}
+
+	if !sameQuery(&cp.Filter, filter) {
+		return nil, fmt.Errorf("filter has changed since the checkpoint was written; rerun with the original filters or delete %s", checkpointPath(c.Output, tag))
+	}
+
+	// Keep the current names for the checkpoints saved from now on
+	cp.Filter = *filter
+	return cp, nil
+}
+
+// sameQuery reports whether two filters request the same listing
+func sameQuery(a, b *myipms.Filter) bool {
+	return myipms.NewQuery(a).PagePath(1) == myipms.NewQuery(b).PagePath(1)
+}
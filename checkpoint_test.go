@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+func TestResumeCheckpoint(t *testing.T) {
+	c := &Config{Output: filepath.Join(t.TempDir(), "domains.txt")}
+	saved := myipms.Filter{OwnerName: "Alibaba Cloud (Singapore) Priv…", OwnerID: 1521829, CountryName: "Singapore", CountryCode: "SGP", RankFrom: 1, RankTo: 100}
+	cp := &Checkpoint{Filter: saved, NextPage: 7, Output: c.Output}
+	if err := cp.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// update-options completed the owner's name; the request is unchanged
+	renamed := saved
+	renamed.OwnerName = "Alibaba Cloud (Singapore) Private Limited"
+	resumed, err := resumeCheckpoint(&renamed, c, "")
+	if err != nil {
+		t.Fatalf("resumeCheckpoint with a renamed owner: %v", err)
+	}
+	if resumed.NextPage != 7 || resumed.Filter.OwnerName != renamed.OwnerName {
+		t.Errorf("resumed at page %d with owner %q, want page 7 with the new name", resumed.NextPage, resumed.Filter.OwnerName)
+	}
+
+	changes := map[string]func(f *myipms.Filter){
+		"owner ID": func(f *myipms.Filter) { f.OwnerID = 615 },
+		"country":  func(f *myipms.Filter) { f.CountryCode = "MYS" },
+		"rank":     func(f *myipms.Filter) { f.RankTo = 200 },
+		"url":      func(f *myipms.Filter) { f.URLFilter = "shop" },
+	}
+	for name, change := range changes {
+		changed := saved
+		change(&changed)
+		if _, err := resumeCheckpoint(&changed, c, ""); err == nil || !strings.Contains(err.Error(), "filter has changed") {
+			t.Errorf("%s changed: resumeCheckpoint() error = %v, want a changed filter", name, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+	help    func()
+}
+
+// commands returns the subcommands in the order the usage lists them
+func commands() []command {
+	return []command{
+		{"scrape", "Scrape domain lists (the default when no command is given)", runScrapeCommand, showScrapeHelp},
+		{"list", "List the filter options, optionally only those matching a text", runListCommand, showListHelp},
+		{"search", "Rank the filter options against a query, as JSON or TSV", runSearchCommand, showSearchHelp},
+		{"resolve", "Show the queries, IDs and URLs a set of filters resolves to", runResolveCommand, showResolveHelp},
+		{"session", "Check, import or clear the saved session cookies", runSessionCommand, showSessionHelp},
+		{"export", "Convert output files to another format or merge them", runExportCommand, showExportHelp},
+		{"update-options", "Refresh the filter option tables from the site (experimental)", runUpdateOptions, showUpdateOptionsHelp},
+		{"help", "Show help for a command", runHelpCommand, showUsage},
+	}
+}
+
+// findCommand returns the named subcommand, or nil if there is none
+func findCommand(name string) *command {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return &cmd
+		}
+	}
+	return nil
+}
+
+// runHelpCommand implements the help command
+func runHelpCommand(args []string) {
+	if len(args) == 0 {
+		showUsage()
+		return
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Printf("Error: unknown command '%s'\n\n", args[0])
+		showUsage()
+		os.Exit(1)
+	}
+	cmd.help()
+}
+
+// showUsage lists the subcommands
+func showUsage() {
+	fmt.Println(`Scrapes domain lists from myip.ms with various filtering options.
+
+USAGE:
+  scraper [COMMAND] [OPTIONS]
+
+COMMANDS:`)
+	for _, cmd := range commands() {
+		fmt.Printf("  %-15s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Println(`
+Run 'scraper help <command>' or 'scraper <command> -help' for the options of a
+command. Options given without a command are scrape options, so
+'scraper -country=USA' is the same as 'scraper scrape -country=USA'.`)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveIDs(t *testing.T) {
+	table := map[string]int{
+		"Amazon.com, Inc":     4638,
+		"Cloudflare, Inc":     615,
+		"Google LLC":          1234,
+		"Hetzner Online GmbH": 45,
+	}
+
+	tests := []struct {
+		inputs  stringList
+		want    []filterValue
+		wantErr string
+	}{
+		{inputs: stringList{"id:615,id:4638"}, want: []filterValue{{Name: "Cloudflare, Inc", ID: 615}, {Name: "Amazon.com, Inc", ID: 4638}}},
+		{inputs: stringList{"id:4638,Amazon.com, Inc"}, want: []filterValue{{Name: "Amazon.com, Inc", ID: 4638}}},
+		{inputs: stringList{"Cloudflare, Inc,id:99,Google LLC"}, want: []filterValue{{Name: "Cloudflare, Inc", ID: 615}, {ID: 99}, {Name: "Google LLC", ID: 1234}}},
+		{inputs: stringList{"id:45", "Amazon.com, Inc"}, want: []filterValue{{Name: "Hetzner Online GmbH", ID: 45}, {Name: "Amazon.com, Inc", ID: 4638}}},
+		{inputs: stringList{"id:abc"}, wantErr: "invalid owner ID 'abc'"},
+		{inputs: stringList{"id:615,Nowhere, Ltd"}, wantErr: "Nowhere"},
+		{inputs: nil, want: []filterValue{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.inputs, "|"), func(t *testing.T) {
+			got, err := resolveIDs("owner", "owner", tt.inputs, 0, table)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveIDs() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveIDs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveIDs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is a YAML file of option values. Keys are flag names without the
+// dash, so ranges and proxies use the same syntax as on the command line.
+//
+//	defaults:
+//	  format: jsonl
+//	profiles:
+//	  us-aws:
+//	    country: USA
+//	    owner: "Amazon.com, Inc"
+//	    rank: 1-10000
+type configFile struct {
+	Defaults map[string]interface{}            `yaml:"defaults"`
+	Profiles map[string]map[string]interface{} `yaml:"profiles"`
+}
+
+// fileOnlyFlags select the config file itself and cannot be set from it
+var fileOnlyFlags = map[string]bool{"config": true, "profile": true, "help": true, "h": true}
+
+// defaultConfigPath returns the config file read when -config is not given
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "myipms-scraper", "config.yaml")
+}
+
+// loadConfigFile reads and parses a config file
+func loadConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var cf configFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return &cf, nil
+}
+
+// applyConfigFile sets every flag not given on the command line from the
+// config file: first from its defaults, then from the selected profile.
+// A missing file is only an error when it was named with -config.
+func applyConfigFile(fs *flag.FlagSet, path string, explicit bool, profile string) error {
+	if path == "" {
+		if profile != "" {
+			return fmt.Errorf("-profile needs a config file (-config)")
+		}
+		return nil
+	}
+
+	cf, err := loadConfigFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit && profile == "" {
+			return nil
+		}
+		return err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	if err := applyOptions(fs, cf.Defaults, set, "defaults"); err != nil {
+		return err
+	}
+
+	if profile == "" {
+		return nil
+	}
+	options, ok := cf.Profiles[profile]
+	if !ok {
+		return fmt.Errorf("unknown profile '%s' in %s (available: %s)", profile, path, strings.Join(cf.profileNames(), ", "))
+	}
+	return applyOptions(fs, options, set, "profile "+profile)
+}
+
+// applyOptions sets the flags named in options, skipping those given on the command line
+func applyOptions(fs *flag.FlagSet, options map[string]interface{}, set map[string]bool, section string) error {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if fs.Lookup(name) == nil || fileOnlyFlags[name] {
+			return fmt.Errorf("%s: unknown option '%s'", section, name)
+		}
+		if set[name] {
+			continue
+		}
+
+		// Multi-value filters take a list and replace any earlier values
+		if list, ok := fs.Lookup(name).Value.(*stringList); ok {
+			*list = nil
+			if items, ok := options[name].([]interface{}); ok {
+				for _, item := range items {
+					if err := setOption(fs, name, item); err != nil {
+						return fmt.Errorf("%s: %s: %v", section, name, err)
+					}
+				}
+				continue
+			}
+		}
+
+		if err := setOption(fs, name, options[name]); err != nil {
+			return fmt.Errorf("%s: %s: %v", section, name, err)
+		}
+	}
+	return nil
+}
+
+// setOption sets the named flag from a YAML scalar
+func setOption(fs *flag.FlagSet, name string, v interface{}) error {
+	value, err := optionValue(v)
+	if err != nil {
+		return err
+	}
+	return fs.Set(name, value)
+}
+
+// optionValue converts a YAML scalar into the string form its flag parses
+func optionValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, float64:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("expected a single value")
+	}
+}
+
+// profileNames returns the names of the profiles, sorted
+func (cf *configFile) profileNames() []string {
+	names := make([]string, 0, len(cf.Profiles))
+	for name := range cf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testConfigFile = `
+defaults:
+  format: jsonl
+  rank: 1-100
+  pages: 5
+  url: shop
+  country: [Germany, France]
+profiles:
+  aws:
+    owner: "Amazon.com, Inc"
+    country: USA
+    format: csv
+    url: ~
+`
+
+// parseWithConfig parses args for a scrape, reading options from a config
+// file with the given contents
+func parseWithConfig(t *testing.T, contents string, args ...string) (*Config, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{}
+	fs := flag.NewFlagSet("scrape", flag.ContinueOnError)
+	registerFlags(fs, config)
+	if err := fs.Parse(append([]string{"-config", path}, args...)); err != nil {
+		t.Fatalf("Parse(%q): %v", args, err)
+	}
+	return config, applyConfigFile(fs, config.ConfigFile, true, config.Profile)
+}
+
+func TestConfigFilePrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		format  string
+		country stringList
+		owner   stringList
+		url     string
+	}{
+		{name: "defaults", format: "jsonl", country: stringList{"Germany", "France"}, url: "shop"},
+		{name: "profile", args: []string{"-profile", "aws"}, format: "csv", country: stringList{"USA"}, owner: stringList{"Amazon.com, Inc"}},
+		{name: "flags", args: []string{"-profile", "aws", "-format", "tsv", "-country", "JP", "-url", "blog"},
+			format: "tsv", country: stringList{"JP"}, owner: stringList{"Amazon.com, Inc"}, url: "blog"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseWithConfig(t, testConfigFile, tt.args...)
+			if err != nil {
+				t.Fatalf("applyConfigFile: %v", err)
+			}
+			if config.Format != tt.format {
+				t.Errorf("format = %q, want %q", config.Format, tt.format)
+			}
+			if !reflect.DeepEqual(config.Country, tt.country) {
+				t.Errorf("country = %q, want %q", config.Country, tt.country)
+			}
+			if !reflect.DeepEqual(config.Owner, tt.owner) {
+				t.Errorf("owner = %q, want %q", config.Owner, tt.owner)
+			}
+			if config.URLFilter != tt.url {
+				t.Errorf("url = %q, want %q", config.URLFilter, tt.url)
+			}
+
+			// Options the profile leaves out come from the defaults section, then the flag defaults
+			if config.RankRange != (IntRange{From: 1, To: 100}) || config.MaxPages != 5 || config.Output != "domains.txt" {
+				t.Errorf("rank = %v, pages = %d, output = %q; want 1-100, 5 and the default output",
+					config.RankRange, config.MaxPages, config.Output)
+			}
+		})
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		args     []string
+		wantErr  string
+	}{
+		{name: "unknown profile", contents: testConfigFile, args: []string{"-profile", "gcp"}, wantErr: "unknown profile 'gcp'"},
+		{name: "unknown option", contents: "defaults:\n  colour: blue\n", wantErr: "defaults: unknown option 'colour'"},
+		{name: "file-only option", contents: "profiles:\n  p:\n    profile: q\n", args: []string{"-profile", "p"}, wantErr: "profile p: unknown option 'profile'"},
+		{name: "list for a scalar", contents: "defaults:\n  format: [csv, tsv]\n", wantErr: "format: expected a single value"},
+		{name: "bad range", contents: "defaults:\n  rank: 100\n", wantErr: "rank: invalid range format"},
+		{name: "bad YAML", contents: "defaults: [", wantErr: "invalid config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseWithConfig(t, tt.contents, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("applyConfigFile() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// maxRecentPages is the number of pages listed in the dashboard's history
+const maxRecentPages = 20
+
+// finalEventTimeout bounds how long Stop waits for the event streams to send
+// the final state
+const finalEventTimeout = 2 * time.Second
+
+// PageStat is the number of domains found on one page
+type PageStat struct {
+	Page    int `json:"page"`
+	Domains int `json:"domains"`
+}
+
+// Progress is the state of a scrape as streamed to the dashboard
+type Progress struct {
+	State          string         `json:"state"`
+	Page           int            `json:"page"`
+	RecentPages    []PageStat     `json:"recent_pages"`
+	Total          int            `json:"total"`
+	Duplicates     int            `json:"duplicates"`
+	Errors         map[string]int `json:"errors"`
+	CaptchaPending bool           `json:"captcha_pending"`
+	NextRequestMs  int64          `json:"next_request_ms"`
+	StartedAt      time.Time      `json:"started_at"`
+	// CaptchaToken is the token of the pending captcha, which pages need to
+	// show and answer it; it stops working once the captcha is solved
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+// Dashboard serves the captcha web interface for the whole scrape and streams
+// progress to it over Server-Sent Events. Its methods do nothing on a nil
+// Dashboard, so callers need not check whether -dashboard is set.
+type Dashboard struct {
+	server *CaptchaServer
+	client *myipms.Client
+
+	mu          sync.Mutex
+	progress    Progress
+	subscribers map[chan struct{}]struct{}
+
+	// closing is closed by Stop to make every event stream send the final
+	// state and end; streams counts the streams opened before that
+	closing chan struct{}
+	stopped bool
+	streams sync.WaitGroup
+}
+
+// startDashboard starts the web interface on bind:port
+func startDashboard(client *myipms.Client, bind string, port int) (*Dashboard, error) {
+	d := &Dashboard{
+		client:      client,
+		subscribers: make(map[chan struct{}]struct{}),
+		closing:     make(chan struct{}),
+		progress: Progress{
+			State:     "starting",
+			Errors:    make(map[string]int),
+			StartedAt: time.Now(),
+		},
+	}
+
+	d.server = NewCaptchaServer(client, bind, port)
+	d.server.dashboard = d
+	if err := d.server.Start(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// URL returns the address of the dashboard, including the access token
+func (d *Dashboard) URL() string {
+	return d.server.URL()
+}
+
+// Stop shuts the web interface down, once every open event stream has sent
+// the final state or finalEventTimeout has passed
+func (d *Dashboard) Stop() {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	close(d.closing)
+	d.mu.Unlock()
+
+	sent := make(chan struct{})
+	go func() {
+		d.streams.Wait()
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(finalEventTimeout):
+	}
+
+	if err := d.server.Stop(); err != nil {
+		fmt.Printf("Error stopping dashboard: %v\n", err)
+	}
+}
+
+// Fetching records that the given page is being requested
+func (d *Dashboard) Fetching(page int) {
+	d.update(func(p *Progress) {
+		p.State = "running"
+		p.Page = page
+	})
+}
+
+// PageDone records a finished page
+func (d *Dashboard) PageDone(page, domains, duplicates, total int) {
+	d.update(func(p *Progress) {
+		p.RecentPages = append(p.RecentPages, PageStat{Page: page, Domains: domains})
+		if len(p.RecentPages) > maxRecentPages {
+			p.RecentPages = p.RecentPages[len(p.RecentPages)-maxRecentPages:]
+		}
+		p.Duplicates += duplicates
+		p.Total = total
+	})
+}
+
+// Error counts an error of the given kind
+func (d *Dashboard) Error(kind string) {
+	d.update(func(p *Progress) {
+		p.Errors[kind]++
+	})
+}
+
+// Finish records the final state of the scrape
+func (d *Dashboard) Finish(state string) {
+	d.update(func(p *Progress) {
+		p.State = state
+	})
+}
+
+// SolveCaptcha shows a new captcha on the dashboard and waits until it is
+// solved there or ctx is cancelled. Unlike the standalone captcha page there
+// is no timeout, since the dashboard is meant to stay open.
+func (d *Dashboard) SolveCaptcha(ctx context.Context) error {
+	captcha, err := d.client.RequestCaptcha(ctx)
+	if err != nil {
+		return err
+	}
+	// Each captcha gets its own token; the dashboard's stays the same
+	if err := d.server.newCaptchaToken(); err != nil {
+		return err
+	}
+	d.server.SetCaptchaData(captcha.Token, captcha.ImageURL)
+
+	d.update(func(p *Progress) {
+		p.State = "captcha"
+		p.CaptchaPending = true
+	})
+	defer d.update(func(p *Progress) {
+		p.CaptchaPending = false
+	})
+	// The captcha cannot be answered again once it is solved
+	defer d.server.revokeCaptchaToken()
+
+	fmt.Printf("Captcha required! Solve it on the dashboard: %s\n", d.URL())
+
+	select {
+	case <-d.server.result:
+		fmt.Println("Captcha solving process completed successfully!")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// update changes the progress and wakes every event stream
+func (d *Dashboard) update(fn func(p *Progress)) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fn(&d.progress)
+	for ch := range d.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// snapshot returns the progress as JSON
+func (d *Dashboard) snapshot() ([]byte, error) {
+	d.mu.Lock()
+	p := d.progress
+	p.RecentPages = append([]PageStat(nil), d.progress.RecentPages...)
+	p.Errors = make(map[string]int, len(d.progress.Errors))
+	for kind, n := range d.progress.Errors {
+		p.Errors[kind] = n
+	}
+	d.mu.Unlock()
+
+	if next := time.Until(d.client.NextRequest()); next > 0 {
+		p.NextRequestMs = next.Milliseconds()
+	}
+	p.CaptchaToken = d.server.captchaAccessToken()
+	return json.Marshal(p)
+}
+
+// handleEvents streams the progress as Server-Sent Events, on every change
+// and at least once a second so the next-request countdown stays current.
+// Once Stop is called it sends the progress a last time and ends the stream.
+func (d *Dashboard) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	changed := make(chan struct{}, 1)
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.subscribers[changed] = struct{}{}
+	d.streams.Add(1)
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.subscribers, changed)
+		d.mu.Unlock()
+		d.streams.Done()
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for closing := false; ; {
+		data, err := d.snapshot()
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+		if closing {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+		case <-d.closing:
+			closing = true
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+	"github.com/ayanrajpoot10/myipms-scraper/myipms/myipmstest"
+)
+
+// streamEvents reads the dashboard's event stream into a channel, which is
+// closed when the stream ends
+func streamEvents(t *testing.T, d *Dashboard) <-chan Progress {
+	t.Helper()
+
+	u, err := url.Parse(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Path = "/events"
+	resp, err := noKeepAlive.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		t.Fatalf("GET /events = %d, want 200", resp.StatusCode)
+	}
+
+	events := make(chan Progress, 100)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var p Progress
+			if err := json.Unmarshal([]byte(data), &p); err != nil {
+				t.Errorf("decoding event %q: %v", data, err)
+				return
+			}
+			events <- p
+		}
+	}()
+	return events
+}
+
+// waitForEvent reads events until one satisfies ok
+func waitForEvent(t *testing.T, events <-chan Progress, want string, ok func(p Progress) bool) Progress {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case p, open := <-events:
+			if !open {
+				t.Fatalf("event stream ended waiting for %s", want)
+			}
+			if ok(p) {
+				return p
+			}
+		case <-timeout:
+			t.Fatalf("no event with %s", want)
+		}
+	}
+}
+
+func TestDashboardEvents(t *testing.T) {
+	srv := myipmstest.NewServer(myipmstest.Options{})
+	defer srv.Close()
+	client, err := myipms.NewClient(myipms.ClientOptions{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dashboard, err := startDashboard(client, "127.0.0.1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dashboard.Stop()
+
+	events := streamEvents(t, dashboard)
+	waitForEvent(t, events, "state starting", func(p Progress) bool { return p.State == "starting" })
+
+	dashboard.Fetching(1)
+	waitForEvent(t, events, "page 1", func(p Progress) bool { return p.State == "running" && p.Page == 1 })
+
+	dashboard.PageDone(1, 50, 0, 50)
+	dashboard.Error("network")
+	p := waitForEvent(t, events, "total 50 and a network error", func(p Progress) bool {
+		return p.Total == 50 && p.Errors["network"] == 1
+	})
+	if len(p.RecentPages) != 1 || p.RecentPages[0] != (PageStat{Page: 1, Domains: 50}) {
+		t.Errorf("recent pages = %v, want page 1 with 50 domains", p.RecentPages)
+	}
+
+	dashboard.Fetching(2)
+	dashboard.PageDone(2, 50, 3, 97)
+	dashboard.Error("network")
+	dashboard.Error("verification")
+	p = waitForEvent(t, events, "page 2", func(p Progress) bool {
+		return p.Page == 2 && p.Total == 97 && p.Errors["network"] == 2 && p.Errors["verification"] == 1
+	})
+	if p.Duplicates != 3 || len(p.RecentPages) != 2 {
+		t.Errorf("duplicates = %d, recent pages = %v; want 3 duplicates over 2 pages", p.Duplicates, p.RecentPages)
+	}
+
+	// Change the state without waking the stream, so only Stop's final event carries it
+	dashboard.mu.Lock()
+	dashboard.progress.State = "done"
+	dashboard.mu.Unlock()
+
+	start := time.Now()
+	dashboard.Stop()
+	if elapsed := time.Since(start); elapsed >= finalEventTimeout {
+		t.Errorf("Stop took %v, want it not to wait for finalEventTimeout", elapsed)
+	}
+
+	var last Progress
+	for p := range events {
+		last = p
+	}
+	if last.State != "done" {
+		t.Errorf("last event state = %q, want done", last.State)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// domainSet is a memory-efficient set of domains. It stores a 64-bit FNV-1a
+// hash per domain instead of the domain itself; at the sizes myip.ms listings
+// reach, a false duplicate from a hash collision is vanishingly unlikely.
+type domainSet map[uint64]struct{}
+
+// domainKey normalises a domain and hashes it
+func domainKey(domain string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(strings.TrimSpace(domain))))
+	return h.Sum64()
+}
+
+// add inserts the domain and reports whether it was not already present
+func (s domainSet) add(domain string) bool {
+	key := domainKey(domain)
+	if _, ok := s[key]; ok {
+		return false
+	}
+	s[key] = struct{}{}
+	return true
+}
+
+// dedupeWriter drops records whose domain was already written, in this run
+// or in an earlier run that appended to the same output
+type dedupeWriter struct {
+	recordWriter
+	seen    domainSet
+	dropped int
+}
+
+// newDedupeWriter wraps w, seeding the seen-set from the existing output file
+func newDedupeWriter(w recordWriter, output, format string) (*dedupeWriter, error) {
+	seen, err := loadDomainSet(output, format)
+	if err != nil {
+		return nil, fmt.Errorf("error loading existing output for -dedupe: %v", err)
+	}
+	return &dedupeWriter{recordWriter: w, seen: seen}, nil
+}
+
+func (dw *dedupeWriter) Write(rec Record) error {
+	if !dw.seen.add(rec.Domain) {
+		dw.dropped++
+		return nil
+	}
+	return dw.recordWriter.Write(rec)
+}
+
+// loadDomainSet reads the domains of an output file written in the given format.
+// A missing file yields an empty set.
+func loadDomainSet(path, format string) (domainSet, error) {
+	seen := make(domainSet)
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return seen, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	err = readRecords(file, format, func(rec Record) error {
+		if rec.Domain != "" {
+			seen.add(rec.Domain)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seen, nil
+}
+
+// readRecords calls fn with each record of an output file in the given format
+func readRecords(r io.Reader, format string, fn func(Record) error) error {
+	switch format {
+	case "csv", "tsv":
+		return readDelimitedRecords(r, format, fn)
+	default:
+		return readLineRecords(r, format, fn)
+	}
+}
+
+// readLineRecords reads txt (one domain per line) and jsonl outputs
+func readLineRecords(r io.Reader, format string, fn func(Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		rec := Record{Site: myipms.Site{Domain: text}}
+		if format == "jsonl" {
+			rec = Record{}
+			if err := json.Unmarshal([]byte(text), &rec); err != nil {
+				return fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readDelimitedRecords reads csv and tsv outputs. Columns are matched by the
+// header row when there is one and taken in recordColumns order otherwise.
+func readDelimitedRecords(r io.Reader, format string, fn func(Record) error) error {
+	cr := csv.NewReader(r)
+	if format == "tsv" {
+		cr.Comma = '\t'
+	}
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	columns := append(append([]string(nil), recordColumns...), "query")
+	for first := true; ; first = false {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if first && len(row) > 0 && row[0] == recordColumns[0] {
+			columns = row
+			continue
+		}
+		if len(row) == 0 || row[0] == "" {
+			continue
+		}
+
+		var rec Record
+		for i, value := range row {
+			if i < len(columns) {
+				rec.setField(columns[i], value)
+			}
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// formatOf infers the output format of a file from its extension, or returns
+// fallback when the extension is not one
+func formatOf(path, fallback string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if isOutputFormat(ext) {
+		return ext
+	}
+	return fallback
+}
+
+// runExportCommand implements the export command: it converts output files
+// to another format, merging them when there are several
+func runExportCommand(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = showExportHelp
+
+	output := fs.String("output", "-", "Output file (- for stdout)")
+	format := fs.String("format", "", "Output format (default: from the output extension, else txt)")
+	inputFormat := fs.String("input-format", "", "Format of the input files (default: from their extensions)")
+	dedupe := fs.Bool("dedupe", true, "Drop domains already exported")
+	inputs := parseInterspersed(fs, args)
+
+	if len(inputs) == 0 {
+		showExportHelp()
+		os.Exit(1)
+	}
+	if *format == "" {
+		*format = formatOf(*output, "txt")
+	}
+	for _, f := range []string{*format, *inputFormat} {
+		if f != "" && !isOutputFormat(f) {
+			fmt.Printf("Error: unknown format '%s' (expected one of %s)\n", f, strings.Join(outputFormats, ", "))
+			os.Exit(1)
+		}
+	}
+
+	formats := make([]string, len(inputs))
+	for i, path := range inputs {
+		formats[i] = *inputFormat
+		if formats[i] == "" {
+			formats[i] = formatOf(path, "txt")
+		}
+		if *output != "-" && sameFile(path, *output) {
+			fmt.Printf("Error: %s is both an input and the output\n", path)
+			os.Exit(1)
+		}
+	}
+
+	// csv and tsv only get a query column when some record has a query
+	tagged := false
+	if *format == "csv" || *format == "tsv" {
+		for i, path := range inputs {
+			err := eachRecord(path, formats[i], func(rec Record) error {
+				if rec.Query != "" {
+					tagged = true
+					return io.EOF
+				}
+				return nil
+			})
+			if err != nil && err != io.EOF {
+				fmt.Printf("Error: %s: %v\n", path, err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	out := os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w, err := newRecordWriter(*format, out, true, tagged)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	seen := make(domainSet)
+	written, dropped := 0, 0
+	for i, path := range inputs {
+		err := eachRecord(path, formats[i], func(rec Record) error {
+			if *dedupe && !seen.add(rec.Domain) {
+				dropped++
+				return nil
+			}
+			written++
+			return w.Write(rec)
+		})
+		if err != nil {
+			fmt.Printf("Error: %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The summary goes to stderr so it stays out of output written to stdout
+	fmt.Fprintf(os.Stderr, "Exported %d records from %d files as %s", written, len(inputs), *format)
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, " (%d duplicates dropped)", dropped)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// eachRecord calls fn with each record of the file at path
+func eachRecord(path, format string, fn func(Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return readRecords(file, format, fn)
+}
+
+// sameFile reports whether two paths name the same existing file
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ai, bi)
+}
+
+// showExportHelp displays the help message of the export command
+func showExportHelp() {
+	fmt.Println(`Converts output files to another format, merging them when several are given.
+
+USAGE:
+  scraper export [OPTIONS] <file>...
+
+The format of each input is taken from its extension (.txt, .jsonl, .csv, .tsv)
+unless -input-format is given. Fields a format does not carry, such as the IP
+and owner of a txt output, are left empty.
+
+EXAMPLES:
+  scraper export -output=domains.csv domains.jsonl
+  scraper export -output=all.txt us.txt de.txt jp.txt
+  scraper export domains.csv | sort
+
+OPTIONS:
+  -output <file>        Output file, - for stdout (default: -)
+  -format <format>      Output format: txt, jsonl, csv, tsv (default: from the output extension, else txt)
+  -input-format <fmt>   Format of every input file (default: from their extensions)
+  -dedupe=false         Keep domains that appear more than once (default: drop them)`)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// filterValue is one resolved value of an owner, host, DNS or country filter.
+// The zero value leaves the filter unset.
+type filterValue struct {
+	Name string
+	ID   int
+	Code string
+}
+
+// tag names the value in a combination tag, falling back to its ID when the
+// option tables do not know it
+func (v filterValue) tag() string {
+	if v.Name == "" && v.ID != 0 {
+		return fmt.Sprintf("id:%d", v.ID)
+	}
+	return v.Name
+}
+
+// filterQuery is one query of a scrape. When multi-value filters fan out into
+// several queries, Tag names the combination of values it stands for, e.g.
+// "country=Germany; owner=Amazon.com, Inc"; it is empty for a single query.
+type filterQuery struct {
+	Filter *myipms.Filter
+	Tag    string
+}
+
+// splitValues splits comma-separated filter values. A value that is a known
+// name as a whole is kept, so names with commas such as "Cloudflare, Inc"
+// survive; otherwise the parts are rejoined greedily into the longest known
+// names, and parts that form none are returned alone to be reported as unknown.
+// Duplicates are dropped.
+func splitValues(inputs []string, known func(string) bool) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, input := range inputs {
+		if whole := strings.TrimSpace(input); known(whole) {
+			add(whole)
+			continue
+		}
+
+		parts := strings.Split(input, ",")
+		for i := 0; i < len(parts); {
+			j := len(parts)
+			for ; j > i+1; j-- {
+				if known(strings.TrimSpace(strings.Join(parts[i:j], ","))) {
+					break
+				}
+			}
+			add(strings.TrimSpace(strings.Join(parts[i:j], ",")))
+			i = j
+		}
+	}
+	return names
+}
+
+// combineFilters returns one query per combination of the filter values, each
+// starting from base. Filters with several values are named in the tags.
+func combineFilters(base *myipms.Filter, countries, owners, hosts, dns []filterValue) []filterQuery {
+	var queries []filterQuery
+	for _, country := range countries {
+		for _, owner := range owners {
+			for _, host := range hosts {
+				for _, d := range dns {
+					f := *base
+					f.CountryName, f.CountryCode = country.Name, country.Code
+					f.OwnerName, f.OwnerID = owner.Name, owner.ID
+					f.HostName, f.HostID = host.Name, host.ID
+					f.DNSName, f.DNSID = d.Name, d.ID
+
+					var tags []string
+					if len(countries) > 1 {
+						tags = append(tags, "country="+country.tag())
+					}
+					if len(owners) > 1 {
+						tags = append(tags, "owner="+owner.tag())
+					}
+					if len(hosts) > 1 {
+						tags = append(tags, "host="+host.tag())
+					}
+					if len(dns) > 1 {
+						tags = append(tags, "dns="+d.tag())
+					}
+
+					queries = append(queries, filterQuery{Filter: &f, Tag: strings.Join(tags, "; ")})
+				}
+			}
+		}
+	}
+	return queries
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+func TestSplitValues(t *testing.T) {
+	known := map[string]bool{
+		"Amazon.com, Inc": true,
+		"Cloudflare, Inc": true,
+		"Google LLC":      true,
+	}
+	isKnown := func(name string) bool {
+		return known[name] || isIDValue(name)
+	}
+
+	tests := []struct {
+		inputs []string
+		want   []string
+	}{
+		{inputs: []string{"Amazon.com, Inc"}, want: []string{"Amazon.com, Inc"}},
+		{inputs: []string{"Amazon.com, Inc,Cloudflare, Inc"}, want: []string{"Amazon.com, Inc", "Cloudflare, Inc"}},
+		{inputs: []string{" Google LLC , Amazon.com, Inc "}, want: []string{"Google LLC", "Amazon.com, Inc"}},
+		{inputs: []string{"id:615,id:4638"}, want: []string{"id:615", "id:4638"}},
+		{inputs: []string{"id:615,Cloudflare, Inc"}, want: []string{"id:615", "Cloudflare, Inc"}},
+		{inputs: []string{"Google LLC", "Google LLC,Amazon.com, Inc", "id:1,id:1"}, want: []string{"Google LLC", "Amazon.com, Inc", "id:1"}},
+		{inputs: []string{"Google LLC,Nowhere, Ltd"}, want: []string{"Google LLC", "Nowhere", "Ltd"}},
+		{inputs: []string{"id:abc,id:7"}, want: []string{"id:abc", "id:7"}},
+		{inputs: []string{"Google LLC,,"}, want: []string{"Google LLC"}},
+		{inputs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.inputs, "|"), func(t *testing.T) {
+			if got := splitValues(tt.inputs, isKnown); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitValues(%q) = %q, want %q", tt.inputs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombineFilters(t *testing.T) {
+	base := &myipms.Filter{URLFilter: "shop"}
+	countries := []filterValue{{Name: "Germany", Code: "DEU"}, {Name: "France", Code: "FRA"}}
+	owners := []filterValue{{Name: "Amazon.com, Inc", ID: 615}, {ID: 99}}
+	none := []filterValue{{}}
+
+	queries := combineFilters(base, countries, owners, none, none)
+
+	wantTags := []string{
+		"country=Germany; owner=Amazon.com, Inc",
+		"country=Germany; owner=id:99",
+		"country=France; owner=Amazon.com, Inc",
+		"country=France; owner=id:99",
+	}
+	var tags []string
+	for _, q := range queries {
+		tags = append(tags, q.Tag)
+	}
+	if !reflect.DeepEqual(tags, wantTags) {
+		t.Fatalf("tags = %q, want %q", tags, wantTags)
+	}
+
+	want := myipms.Filter{URLFilter: "shop", CountryName: "France", CountryCode: "FRA", OwnerID: 99}
+	if got := *queries[3].Filter; got != want {
+		t.Errorf("last filter = %+v, want %+v", got, want)
+	}
+	if queries[0].Filter == queries[1].Filter || base.CountryName != "" {
+		t.Error("combineFilters shares or modifies the base filter")
+	}
+
+	// A single combination needs no tag
+	single := combineFilters(base, countries[:1], none, none, none)
+	if len(single) != 1 || single[0].Tag != "" || single[0].Filter.CountryCode != "DEU" {
+		t.Errorf("single query = %+v, want one untagged query for Germany", single)
+	}
+}
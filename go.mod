@@ -1,3 +1,8 @@
 module github.com/ayanrajpoot10/myipms-scraper
 
 go 1.21
+
+require (
+	golang.org/x/net v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
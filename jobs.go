@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+	"gopkg.in/yaml.v3"
+)
+
+// jobsFile lists the scrapes of a -jobs run. Each job takes the same keys as
+// a config file profile, plus an optional name and profile to start from.
+//
+//	jobs:
+//	  - name: us-aws
+//	    country: USA
+//	    owner: "Amazon.com, Inc"
+//	    output: us-aws.txt
+//	  - profile: cf-dns
+//	    output: cf-dns.txt
+type jobsFile struct {
+	Jobs []map[string]interface{} `yaml:"jobs"`
+}
+
+// sharedFlags configure the client or the run as a whole, so a job cannot set them
+var sharedFlags = map[string]bool{
+	"base-url": true, "proxy": true, "rate": true, "session": true, "cookies": true,
+	"captcha": true, "captcha-image": true, "captcha-bind": true, "captcha-port": true,
+	"dashboard": true, "jobs": true, "list": true, "options-file": true,
+}
+
+// job is one scrape of a -jobs run or one query of a multi-value filter
+type job struct {
+	Name       string
+	Config     *Config
+	Query      filterQuery
+	Checkpoint *Checkpoint
+	Result     *scrapeResult
+	Err        error
+	Skipped    bool
+}
+
+// loadJobs reads the jobs file. Every job starts from the command-line args,
+// then the config file's defaults and the job's profile, then its own options.
+func loadJobs(path string, args []string) ([]*job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading jobs file: %w", err)
+	}
+
+	var jf jobsFile
+	if err := yaml.Unmarshal(data, &jf); err != nil {
+		return nil, fmt.Errorf("invalid jobs file %s: %v", path, err)
+	}
+	if len(jf.Jobs) == 0 {
+		return nil, fmt.Errorf("no jobs in %s", path)
+	}
+
+	jobs := make([]*job, 0, len(jf.Jobs))
+	outputs := make(map[string]string)
+	for i, entry := range jf.Jobs {
+		j := &job{Name: fmt.Sprintf("job %d", i+1), Config: &Config{}}
+
+		options := make(map[string]interface{}, len(entry))
+		for key, value := range entry {
+			options[key] = value
+		}
+		if name, ok := options["name"].(string); ok && name != "" {
+			j.Name = name
+		}
+		profile, _ := options["profile"].(string)
+		delete(options, "name")
+		delete(options, "profile")
+
+		fs := flag.NewFlagSet(j.Name, flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		registerFlags(fs, j.Config)
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if profile == "" {
+			profile = j.Config.Profile
+		}
+		if err := applyConfigFile(fs, j.Config.ConfigFile, isFlagSet(fs, "config"), profile); err != nil {
+			return nil, fmt.Errorf("%s: %v", j.Name, err)
+		}
+
+		for key := range options {
+			if sharedFlags[key] {
+				return nil, fmt.Errorf("%s: option '%s' applies to the whole run and cannot be set per job", j.Name, key)
+			}
+		}
+		if err := applyOptions(fs, options, nil, j.Name); err != nil {
+			return nil, err
+		}
+
+		if other, ok := outputs[j.Config.Output]; ok {
+			return nil, fmt.Errorf("%s and %s both write to %s; give each job its own output", other, j.Name, j.Config.Output)
+		}
+		outputs[j.Config.Output] = j.Name
+
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// runJobs runs every job of the jobs file, expanding jobs with multi-value
+// filters into one job per query. args are the scrape command's arguments.
+func runJobs(config *Config, args []string) {
+	jobs, err := loadJobs(config.JobsFile, args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Shared options such as the proxy are validated once for the whole run
+	if _, err := validateAndResolveFilters(config); err != nil {
+		handleValidationError(err)
+		return
+	}
+
+	var expanded []*job
+	for _, j := range jobs {
+		queries, err := validateAndResolveFilters(j.Config)
+		if err != nil {
+			fmt.Printf("Invalid job %s:\n", j.Name)
+			handleValidationError(err)
+			return
+		}
+		sub, err := expandJob(j, queries)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		expanded = append(expanded, sub...)
+	}
+
+	if executeJobs(config, expanded) {
+		os.Exit(1)
+	}
+}
+
+// runFanOut runs the queries of a multi-value filter in sequence over one
+// client, merging their results into the configured output
+func runFanOut(config *Config, queries []filterQuery) {
+	jobs, err := expandJob(&job{Config: config}, queries)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Multi-value filter: %d queries, merged into %s\n", len(jobs), config.Output)
+	if executeJobs(config, jobs) {
+		os.Exit(1)
+	}
+}
+
+// expandJob returns the jobs that scrape the queries of j, one per query.
+// Several queries share j's output, deduplicated, with each record tagged
+// with the query that found it.
+func expandJob(j *job, queries []filterQuery) ([]*job, error) {
+	if len(queries) == 1 {
+		checkpoint, err := newCheckpoint(queries[0], j.Config)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", j.Name, err)
+		}
+		j.Query, j.Checkpoint = queries[0], checkpoint
+		return []*job{j}, nil
+	}
+
+	jobs := make([]*job, 0, len(queries))
+	for _, q := range queries {
+		c := *j.Config
+		c.Dedupe = true
+
+		name := q.Tag
+		if j.Name != "" {
+			name = fmt.Sprintf("%s [%s]", j.Name, q.Tag)
+		}
+
+		checkpoint, err := newCheckpoint(q, &c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		jobs = append(jobs, &job{Name: name, Config: &c, Query: q, Checkpoint: checkpoint})
+	}
+	return jobs, nil
+}
+
+// executeJobs runs the jobs in sequence over one client, so a solved captcha
+// serves them all, and prints a combined summary. It reports whether any job
+// failed or was skipped.
+func executeJobs(config *Config, jobs []*job) bool {
+	ctx, stop := notifyContext()
+	defer stop()
+
+	client := newClient(config)
+	dashboard := newDashboard(client, config)
+	defer dashboard.Stop()
+
+	stopped := false
+	for i, j := range jobs {
+		if stopped {
+			j.Skipped = true
+			continue
+		}
+
+		fmt.Printf("\n=== Job %d/%d: %s ===\n", i+1, len(jobs), j.Name)
+		if j.Checkpoint.Done {
+			fmt.Println("Already complete; skipping")
+			continue
+		}
+		displayScrapingFilter(j.Query.Filter, j.Config)
+
+		j.Result, j.Err = runScrape(ctx, client, j.Config, j.Query.Filter, j.Checkpoint, dashboard)
+		if j.Err != nil {
+			fmt.Printf("Error: %v\n", j.Err)
+		}
+
+		// A rate limit applies to every job from this IP
+		if errors.Is(j.Err, myipms.ErrRateLimited) || (j.Result != nil && j.Result.Interrupted) {
+			stopped = true
+		}
+	}
+
+	saveSession(client)
+	printJobsSummary(os.Stdout, jobs)
+	removeFinishedCheckpoints(jobs)
+
+	failed := false
+	for _, j := range jobs {
+		failed = failed || j.Err != nil || j.Skipped
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		dashboard.Finish("interrupted")
+	case stopped:
+		dashboard.Finish("rate_limited")
+	case failed:
+		dashboard.Finish("failed")
+	default:
+		dashboard.Finish("done")
+	}
+	return failed
+}
+
+// removeFinishedCheckpoints deletes the checkpoints of multi-value filter
+// queries once every one of them is complete
+func removeFinishedCheckpoints(jobs []*job) {
+	for _, j := range jobs {
+		if j.Query.Tag != "" && !j.Checkpoint.Done {
+			return
+		}
+	}
+	for _, j := range jobs {
+		if j.Query.Tag == "" {
+			continue
+		}
+		if err := j.Checkpoint.remove(); err != nil {
+			fmt.Printf("Warning: could not remove checkpoint: %v\n", err)
+		}
+	}
+}
+
+// printJobsSummary prints one line per job and the combined totals to out
+func printJobsSummary(out io.Writer, jobs []*job) {
+	fmt.Fprintln(out, "\nSummary:")
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  JOB\tSTATUS\tPAGES\tDOMAINS\tOUTPUT")
+
+	var done, failed, pages, total int
+	for _, j := range jobs {
+		status := "done"
+		switch {
+		case j.Checkpoint.Done && j.Result == nil:
+			status = "done (earlier run)"
+			done++
+		case j.Skipped:
+			status = "skipped"
+		case j.Err != nil:
+			status = "failed: " + j.Err.Error()
+			failed++
+		case j.Result.Interrupted:
+			status = fmt.Sprintf("interrupted (resume from page %d)", j.Result.NextPage)
+		default:
+			done++
+		}
+
+		var jobPages, jobTotal int
+		if j.Result != nil {
+			jobPages, jobTotal = j.Result.Pages, j.Result.Total
+		}
+		pages += jobPages
+		total += jobTotal
+
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%d\t%s\n", j.Name, status, jobPages, jobTotal, j.Config.Output)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "Jobs: %d done, %d failed, %d total. Pages: %d. Domains: %d\n", done, failed, len(jobs), pages, total)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms/myipmstest"
+)
+
+// writeJobsFile writes a jobs file with the given contents
+func writeJobsFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "jobs.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobs    string
+		args    []string
+		want    []Config
+		wantErr string
+	}{
+		{
+			name: "job options override shared flags",
+			jobs: `
+jobs:
+  - name: us
+    output: us.jsonl
+  - country: [Japan, Germany]
+    format: csv
+    output: jp.csv
+`,
+			args: []string{"-country", "USA", "-format", "jsonl", "-pages", "3"},
+			want: []Config{
+				{Output: "us.jsonl", Format: "jsonl", Country: stringList{"USA"}, MaxPages: 3},
+				{Output: "jp.csv", Format: "csv", Country: stringList{"Japan", "Germany"}, MaxPages: 3},
+			},
+		},
+		{
+			name:    "shared flag",
+			jobs:    "jobs:\n  - output: a.txt\n    proxy: http://proxy:8080\n",
+			wantErr: "job 1: option 'proxy' applies to the whole run and cannot be set per job",
+		},
+		{
+			name:    "dashboard per job",
+			jobs:    "jobs:\n  - name: a\n    output: a.txt\n    dashboard: true\n",
+			wantErr: "a: option 'dashboard' applies to the whole run",
+		},
+		{
+			name:    "unknown option",
+			jobs:    "jobs:\n  - output: a.txt\n    colour: blue\n",
+			wantErr: "unknown option 'colour'",
+		},
+		{
+			name:    "duplicate output",
+			jobs:    "jobs:\n  - name: a\n    output: same.txt\n  - name: b\n    output: same.txt\n",
+			wantErr: "a and b both write to same.txt",
+		},
+		{
+			name:    "missing outputs share the default",
+			jobs:    "jobs:\n  - country: USA\n  - country: Japan\n",
+			wantErr: "job 1 and job 2 both write to domains.txt",
+		},
+		{
+			name:    "missing outputs share -output",
+			jobs:    "jobs:\n  - country: USA\n  - country: Japan\n",
+			args:    []string{"-output", "all.txt"},
+			wantErr: "job 1 and job 2 both write to all.txt",
+		},
+		{
+			name:    "no jobs",
+			jobs:    "jobs: []\n",
+			wantErr: "no jobs in",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeJobsFile(t, tt.jobs)
+			args := append([]string{"-config", "", "-jobs", path}, tt.args...)
+
+			jobs, err := loadJobs(path, args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadJobs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadJobs: %v", err)
+			}
+
+			if len(jobs) != len(tt.want) {
+				t.Fatalf("got %d jobs, want %d", len(jobs), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := jobs[i].Config
+				if got.Output != want.Output || got.Format != want.Format || got.Country.String() != want.Country.String() || got.MaxPages != want.MaxPages {
+					t.Errorf("job %d: output %q, format %q, country %q, pages %d; want %q, %q, %q, %d", i+1,
+						got.Output, got.Format, got.Country.String(), got.MaxPages,
+						want.Output, want.Format, want.Country.String(), want.MaxPages)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteJobs(t *testing.T) {
+	tests := []struct {
+		name        string
+		rateLimited bool
+		// outputs of the jobs; "missing/" puts one in a directory that does not exist
+		outputs     []string
+		wantStatus  []string
+		wantSummary string
+		wantFailed  bool
+	}{
+		{
+			name:        "all done",
+			outputs:     []string{"a.txt", "b.txt"},
+			wantStatus:  []string{"done", "done"},
+			wantSummary: "Jobs: 2 done, 0 failed, 2 total. Pages: 2. Domains: 100",
+		},
+		{
+			name:        "one job fails, the others keep going",
+			outputs:     []string{"a.txt", "missing/b.txt", "c.txt"},
+			wantStatus:  []string{"done", "failed", "done"},
+			wantSummary: "Jobs: 2 done, 1 failed, 3 total. Pages: 2. Domains: 100",
+			wantFailed:  true,
+		},
+		{
+			name:        "rate limit skips the rest",
+			rateLimited: true,
+			outputs:     []string{"a.txt", "b.txt"},
+			wantStatus:  []string{"failed", "skipped"},
+			wantSummary: "Jobs: 0 done, 1 failed, 2 total. Pages: 0. Domains: 0",
+			wantFailed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myipmstest.NewServer(myipmstest.Options{})
+			defer srv.Close()
+			srv.SetRateLimited(tt.rateLimited)
+
+			dir := t.TempDir()
+			var contents strings.Builder
+			contents.WriteString("jobs:\n")
+			for _, output := range tt.outputs {
+				contents.WriteString("  - output: " + filepath.Join(dir, output) + "\n")
+			}
+			path := writeJobsFile(t, contents.String())
+
+			args := []string{"-config", "", "-session", "", "-captcha", "terminal", "-base-url", srv.URL, "-pages", "1", "-jobs", path}
+			config, _, _ := parseConfig("scrape", args, nil)
+			loaded, err := loadJobs(path, args)
+			if err != nil {
+				t.Fatalf("loadJobs: %v", err)
+			}
+			var jobs []*job
+			for _, j := range loaded {
+				queries, err := validateAndResolveFilters(j.Config)
+				if err != nil {
+					t.Fatalf("%s: %v", j.Name, err)
+				}
+				sub, err := expandJob(j, queries)
+				if err != nil {
+					t.Fatal(err)
+				}
+				jobs = append(jobs, sub...)
+			}
+
+			if failed := executeJobs(config, jobs); failed != tt.wantFailed {
+				t.Errorf("executeJobs() failed = %v, want %v", failed, tt.wantFailed)
+			}
+
+			for i, j := range jobs {
+				status := "done"
+				switch {
+				case j.Skipped:
+					status = "skipped"
+				case j.Err != nil:
+					status = "failed"
+				}
+				if status != tt.wantStatus[i] {
+					t.Errorf("%s: status %s (error %v), want %s", j.Name, status, j.Err, tt.wantStatus[i])
+				}
+			}
+
+			var summary bytes.Buffer
+			printJobsSummary(&summary, jobs)
+			if !strings.Contains(summary.String(), tt.wantSummary) {
+				t.Errorf("summary:\n%s\nwant %q", summary.String(), tt.wantSummary)
+			}
+		})
+	}
+}
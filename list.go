@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// optionKinds maps the kinds of filter option, and their singular forms, to
+// the catalog kind and the scrape flag that takes them
+var optionKinds = map[string]struct{ kind, flag string }{
+	"countries": {myipms.CatalogCountries, "country"},
+	"country":   {myipms.CatalogCountries, "country"},
+	"owners":    {myipms.CatalogOwners, "owner"},
+	"owner":     {myipms.CatalogOwners, "owner"},
+	"hosts":     {myipms.CatalogHosts, "host"},
+	"host":      {myipms.CatalogHosts, "host"},
+	"dns":       {myipms.CatalogDNS, "dns"},
+}
+
+// optionTitles are the headings of the option tables
+var optionTitles = map[string]string{
+	myipms.CatalogCountries: "COUNTRIES",
+	myipms.CatalogOwners:    "OWNERS/HOSTING PROVIDERS",
+	myipms.CatalogHosts:     "HOSTS",
+	myipms.CatalogDNS:       "DNS RECORDS",
+}
+
+// optionTable returns the option table of a catalog kind, name to ID or
+// country code
+func optionTable(kind string) map[string]string {
+	ids := func(table map[string]int) map[string]string {
+		m := make(map[string]string, len(table))
+		for name, id := range table {
+			m[name] = strconv.Itoa(id)
+		}
+		return m
+	}
+
+	switch kind {
+	case myipms.CatalogCountries:
+		return myipms.Countries
+	case myipms.CatalogOwners:
+		return ids(myipms.Owners)
+	case myipms.CatalogHosts:
+		return ids(myipms.Hosts)
+	default:
+		return ids(myipms.DNS)
+	}
+}
+
+// runListCommand implements the list command
+func runListCommand(args []string) {
+	config, _, rest := parseConfig("list", args, showListHelp)
+	catalog := useOptionsFile(config)
+
+	kinds := myipms.CatalogKinds
+	regions := false
+	if len(rest) > 0 {
+		if k, ok := optionKinds[strings.ToLower(rest[0])]; ok {
+			kinds, rest = []string{k.kind}, rest[1:]
+		} else if isRegionKind(rest[0]) {
+			kinds, regions, rest = nil, true, rest[1:]
+		}
+	}
+	if len(rest) > 1 {
+		fmt.Printf("Error: unexpected argument '%s'\n", rest[1])
+		os.Exit(1)
+	}
+	text := ""
+	if len(rest) == 1 {
+		text = rest[0]
+	}
+
+	if catalog != nil {
+		fmt.Printf("Options from %s (updated %s)\n\n", config.OptionsFile, catalog.UpdatedAt.Format("2006-01-02"))
+	}
+
+	found := 0
+	for _, kind := range kinds {
+		found += listOptions(kind, text)
+	}
+	if regions {
+		found += listRegions(text)
+	}
+	if text != "" && found == 0 {
+		fmt.Printf("No options match '%s'\n", text)
+		os.Exit(1)
+	}
+}
+
+// listOptions prints the options of kind whose name contains text, ignoring
+// case, with their IDs. It returns how many it printed.
+func listOptions(kind, text string) int {
+	table := optionTable(kind)
+	lower := strings.ToLower(text)
+
+	names := make([]string, 0, len(table))
+	for name := range table {
+		if strings.Contains(strings.ToLower(name), lower) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 && text != "" {
+		return 0
+	}
+	sort.Strings(names)
+
+	title := optionTitles[kind]
+	fmt.Printf("%s:\n%s\n", title, strings.Repeat("-", len(title)+1))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", name, table[name])
+	}
+	w.Flush()
+
+	fmt.Printf("\nTotal: %d %s\n\n", len(names), kind)
+	return len(names)
+}
+
+// isRegionKind reports whether a list or resolve argument names the regions
+func isRegionKind(arg string) bool {
+	arg = strings.ToLower(arg)
+	return arg == "regions" || arg == "region"
+}
+
+// listRegions prints the regions whose name or alias contains text, ignoring
+// case, with the countries they expand to. It returns how many it printed.
+func listRegions(text string) int {
+	lower := strings.ToLower(text)
+	matches := func(r myipms.Region) bool {
+		for _, name := range append([]string{r.Name}, r.Aliases...) {
+			if strings.Contains(strings.ToLower(name), lower) {
+				return true
+			}
+		}
+		return false
+	}
+
+	title := "REGIONS"
+	fmt.Printf("%s:\n%s\n", title, strings.Repeat("-", len(title)+1))
+
+	found := 0
+	for _, r := range myipms.Regions {
+		if !matches(r) {
+			continue
+		}
+		found++
+
+		name := r.Name
+		if len(r.Aliases) > 0 {
+			name += " (" + strings.Join(r.Aliases, ", ") + ")"
+		}
+		countries := r.Countries()
+		fmt.Printf("  %s: %d countries\n    %s\n", name, len(countries), strings.Join(countries, ", "))
+	}
+
+	fmt.Printf("\nTotal: %d regions\n\n", found)
+	return found
+}
+
+// runResolveCommand implements the resolve command
+func runResolveCommand(args []string) {
+	config, fs, rest := parseConfig("resolve", args, showResolveHelp)
+	useOptionsFile(config)
+
+	// resolve <kind> <name>... is short for resolve -<kind>=<name>...
+	if len(rest) > 0 {
+		k, ok := optionKinds[strings.ToLower(rest[0])]
+		if isRegionKind(rest[0]) {
+			k.flag, ok = "region", true
+		}
+		if !ok || len(rest) < 2 {
+			showResolveHelp()
+			os.Exit(1)
+		}
+		for _, name := range rest[1:] {
+			fs.Set(k.flag, name)
+		}
+	}
+
+	queries, err := validateAndResolveFilters(config)
+	if err != nil {
+		handleValidationError(err)
+		return
+	}
+
+	base := strings.TrimRight(config.BaseURL, "/")
+	for i, q := range queries {
+		if len(queries) > 1 {
+			fmt.Printf("Query %d/%d: %s\n", i+1, len(queries), q.Tag)
+		}
+		fmt.Printf("  Filter: %s\n", filterDescription(q.Filter))
+		fmt.Printf("  URL:    %s\n", base+myipms.NewQuery(q.Filter).PagePath(1))
+	}
+}
+
+// showListHelp displays the help message of the list command
+func showListHelp() {
+	fmt.Println(`Lists the filter options with their myip.ms IDs (country codes for countries).
+
+USAGE:
+  scraper list [OPTIONS] [countries|owners|hosts|dns|regions] [text]
+
+Without a kind every table is listed. With a text only the names containing it
+are listed, ignoring case. Regions, the groups of countries -region takes, are
+only listed when asked for.
+
+EXAMPLES:
+  scraper list owners cloudflare
+  scraper list countries
+  scraper list regions asia
+  scraper list amazon
+
+OPTIONS:
+  -options-file <file>  Option tables written by update-options (default: none, the built-in tables)
+  -config <file>        Config file, e.g. to set options-file
+  -profile <name>       Profile of the config file to use`)
+}
+
+// showResolveHelp displays the help message of the resolve command
+func showResolveHelp() {
+	fmt.Println(`Shows what a set of filters resolves to, without scraping: the names and IDs
+of every filter and the URL of the first page. Multi-value filters show one
+query per combination.
+
+USAGE:
+  scraper resolve [OPTIONS]
+  scraper resolve [OPTIONS] <country|owner|host|dns|region> <name>...
+
+EXAMPLES:
+  scraper resolve -country=Germany -owner="Amazon.com, Inc"
+  scraper resolve owner "Cloudflare, Inc" "Google Inc"
+  scraper resolve region EU
+  scraper resolve -profile=us-aws
+
+OPTIONS:
+  Takes the filter options of the scrape command (-country, -region, -owner, -owner-id,
+  -host, -dns, -url, -rank, -ip, -visitors), plus -base-url, -options-file,
+  -config and -profile. Unknown names are reported with suggestions.`)
+}
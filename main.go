@@ -1,77 +1,192 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
 )
 
 func main() {
-	config := parseFlags()
+	name, args := "scrape", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Printf("Error: unknown command '%s'\n\n", name)
+		showUsage()
+		os.Exit(1)
+	}
+	cmd.run(args)
+}
 
+// runScrapeCommand implements the scrape command, the default
+func runScrapeCommand(args []string) {
+	config, _, rest := parseConfig("scrape", args, showScrapeHelp)
+	if len(rest) > 0 {
+		fmt.Printf("Error: unexpected argument '%s'\n", rest[0])
+		os.Exit(1)
+	}
+	catalog := useOptionsFile(config)
+
+	// -list is kept from before the list command
 	if config.List {
-		showSpecificOptions(config.Owner, config.Country, config.Host, config.DNSRecord)
+		if catalog != nil {
+			fmt.Printf("Options from %s (updated %s)\n\n", config.OptionsFile, catalog.UpdatedAt.Format("2006-01-02"))
+		}
+		showSpecificOptions(config.Owner.String(), config.Country.String(), config.Host.String(), config.DNSRecord.String())
 		return
 	}
 
-	filter, err := validateAndResolveFilters(config)
+	if config.JobsFile != "" {
+		runJobs(config, args)
+		return
+	}
+
+	queries, err := validateAndResolveFilters(config)
 	if err != nil {
 		handleValidationError(err)
 		return
 	}
 
+	if len(queries) > 1 {
+		runFanOut(config, queries)
+		return
+	}
+	filter := queries[0].Filter
+
+	checkpoint, err := newCheckpoint(queries[0], config)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	displayScrapingFilter(filter, config)
+	if config.Resume {
+		fmt.Printf("Resuming from page %d (%d domains so far)\n", checkpoint.NextPage, checkpoint.Total)
+	}
+
+	ctx, stop := notifyContext()
+	defer stop()
 
-	httpClient := newHTTPClient(config.ProxyURL, config.ProxyUser, config.ProxyPass)
-	scraper := NewScraper(httpClient, filter)
+	client := newClient(config)
+	dashboard := newDashboard(client, config)
+	defer dashboard.Stop()
 
-	file, err := os.OpenFile(config.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	result, err := runScrape(ctx, client, config, filter, checkpoint, dashboard)
+	saveSession(client)
 	if err != nil {
-		fmt.Printf("Error opening output file: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
+		if !errors.Is(err, myipms.ErrRateLimited) {
+			dashboard.Finish("failed")
+		}
+		// os.Exit skips the deferred Stop, which sends the final state
+		dashboard.Stop()
 		os.Exit(1)
 	}
-	defer file.Close()
-
-	totalDomains := 0
-	page := config.StartPage
-	maxPage := config.StartPage + config.MaxPages
-
-	isUnlimited := config.MaxPages == 0
-
-	for isUnlimited || page < maxPage {
-		domains, err := scraper.fetchPage(page)
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			if strings.Contains(err.Error(), "cookies expired") {
-				captchaErr := solveCaptcha(httpClient)
-				if captchaErr != nil {
-					fmt.Printf("Failed to solve captcha: %v\n", captchaErr)
-					fmt.Println("Please restart the program and try again.")
-					os.Exit(1)
-				}
-				continue
-			} else if strings.Contains(err.Error(), "IP limit exceeded") {
-				fmt.Println("IP address has been rate limited. Please:")
-				fmt.Println("1. Use a proxy or VPN to change your IP address")
-				fmt.Println("2. If using mobile internet, turn airplane mode on/off to get a new IP")
-				os.Exit(1)
-			}
-			continue
-		}
 
-		if len(domains) == 0 {
-			fmt.Println("No domains found")
-			break
-		}
+	if config.Dedupe {
+		fmt.Printf("Duplicates dropped: %d\n", result.Duplicates)
+	}
 
-		for _, domain := range domains {
-			file.WriteString(domain + "\n")
-		}
+	if result.Interrupted {
+		dashboard.Finish("interrupted")
+		fmt.Printf("\nInterrupted. Total domains: %d\n", result.Total)
+		fmt.Printf("Resume from page %d with: %s\n", result.NextPage, checkpoint.resumeHint(result.NextPage))
+		return
+	}
 
-		totalDomains += len(domains)
-		fmt.Printf("Page %d: Found %d domains (Total: %d)\n", page, len(domains), totalDomains)
-		page++
+	dashboard.Finish("done")
+	fmt.Printf("Scraping complete! Total domains: %d\n", result.Total)
+}
+
+// notifyContext returns a context cancelled by the first SIGINT or SIGTERM
+func notifyContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// Restore default signal handling so a second Ctrl-C exits immediately
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// newClient creates the client for the configured site, proxy and session,
+// exiting on error
+func newClient(config *Config) *myipms.Client {
+	client, err := myipms.NewClient(myipms.ClientOptions{
+		BaseURL:   config.BaseURL,
+		ProxyURL:  config.ProxyURL,
+		ProxyUser: config.ProxyUser,
+		ProxyPass: config.ProxyPass,
+
+		RequestInterval: requestInterval(config.Rate),
+		SessionFile:     config.SessionFile,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := client.SessionError(); err != nil {
+		fmt.Printf("Warning: %v; starting from the built-in cookies\n", err)
+	}
+	if config.CookiesFile != "" {
+		importCookies(client, config.CookiesFile)
+	}
+	return client
+}
+
+// newDashboard starts the dashboard when -dashboard is set, exiting on error.
+// It returns nil otherwise.
+func newDashboard(client *myipms.Client, config *Config) *Dashboard {
+	if !config.Dashboard {
+		return nil
+	}
+
+	dashboard, err := startDashboard(client, config.CaptchaBind, config.CaptchaPort)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Dashboard: %s\n", dashboard.URL())
+	if err := openBrowser(dashboard.URL()); err != nil {
+		fmt.Println("Could not open browser automatically.")
+	}
+	return dashboard
+}
+
+// importCookies loads browser cookies into the client, exiting on error
+func importCookies(client *myipms.Client, path string) {
+	missing, err := client.ImportCookies(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported cookies from %s\n", path)
+	if len(missing) > 0 {
+		fmt.Printf("Warning: %s missing from %s; the site may still ask for verification\n", strings.Join(missing, ", "), path)
 	}
+}
 
-	fmt.Printf("Scraping complete! Total domains: %d\n", totalDomains)
+// saveSession persists the client's cookies so the next run can reuse them
+func saveSession(client *myipms.Client) {
+	if err := client.SaveSession(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// requestInterval converts a requests-per-second rate into the minimum time between requests
+func requestInterval(rate float64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / rate)
 }
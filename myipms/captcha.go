@@ -0,0 +1,88 @@
+package myipms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const (
+	captchaTargetPath = "/ajax_table/sites/1"
+	captchaFinalPath  = "/browse/sites/1"
+)
+
+// Captcha is a Human Verification challenge issued by myip.ms
+type Captcha struct {
+	Token    string
+	ImageURL string
+}
+
+// RequestCaptcha asks myip.ms for a new Human Verification challenge
+func (c *Client) RequestCaptcha(ctx context.Context) (*Captcha, error) {
+	postData := url.Values{
+		"x":                    {"150"},
+		"y":                    {"58"},
+		"g_recaptcha_loaded":   {"no"},
+		"captcha_token":        {""},
+		"g_recaptcha_response": {""},
+	}
+
+	resp, err := c.Post(ctx, c.BaseURL()+captchaTargetPath, postData)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting captcha: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading captcha response: %w", err)
+	}
+
+	captcha := captchaFromHTML(string(body), c.BaseURL())
+	if captcha == nil {
+		return nil, ErrNoCaptcha
+	}
+	return captcha, nil
+}
+
+// SubmitCaptcha submits an answer to the challenge. If myip.ms rejects the
+// answer it returns ErrCaptchaRejected together with the next challenge to
+// solve, which may be nil if the response did not contain one.
+func (c *Client) SubmitCaptcha(ctx context.Context, captcha *Captcha, answer string) (*Captcha, error) {
+	finalData := url.Values{
+		"x":                  {"0"},
+		"y":                  {"0"},
+		"g_recaptcha_loaded": {"no"},
+		"captcha_token":      {captcha.Token},
+		"p_captcha_response": {answer},
+	}
+
+	resp, err := c.Post(ctx, c.BaseURL()+captchaFinalPath, finalData)
+	if err != nil {
+		return nil, fmt.Errorf("error validating captcha: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading validation response: %w", err)
+	}
+
+	if !strings.Contains(string(body), "captcha_token") {
+		return nil, nil
+	}
+
+	return captchaFromHTML(string(body), c.BaseURL()), ErrCaptchaRejected
+}
+
+// captchaFromHTML extracts the challenge from a Human Verification form
+func captchaFromHTML(content, baseURL string) *Captcha {
+	token := extractCaptchaToken(content)
+	imageURL := extractCaptchaURL(content, baseURL)
+	if token == "" || imageURL == "" {
+		return nil
+	}
+	return &Captcha{Token: token, ImageURL: imageURL}
+}
@@ -0,0 +1,221 @@
+package myipms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Catalog kinds, one per filter option table
+const (
+	CatalogOwners    = "owners"
+	CatalogHosts     = "hosts"
+	CatalogDNS       = "dns"
+	CatalogCountries = "countries"
+)
+
+// CatalogKinds lists the catalog kinds in the order they are updated
+var CatalogKinds = []string{CatalogOwners, CatalogHosts, CatalogDNS, CatalogCountries}
+
+// catalogSource describes where the site lists the options of one kind:
+// either a paginated table whose rows link to linkPrefix+ID, or a <select>
+// on a single page
+type catalogSource struct {
+	pathTemplate string
+	linkPrefix   string
+	selectName   string
+}
+
+// catalogSources lists where each kind is scraped from. Only /browse/sites
+// is known from the sites listing; the other paths, the link prefixes and the
+// countryID select have not been checked against the live site, and the tests
+// run against myipmstest, which serves the same paths. An update that finds
+// no entries for a kind is therefore reported as a failure by the caller, and
+// the update-options command that uses them is marked experimental.
+var catalogSources = map[string]catalogSource{
+	CatalogOwners:    {pathTemplate: "/ajax_table/web_hosting/%d", linkPrefix: "/view/web_hosting/"},
+	CatalogHosts:     {pathTemplate: "/ajax_table/hosts/%d", linkPrefix: "/view/hosts/"},
+	CatalogDNS:       {pathTemplate: "/ajax_table/dns/%d", linkPrefix: "/view/dns/"},
+	CatalogCountries: {pathTemplate: "/browse/sites/%d", selectName: "countryID"},
+}
+
+// Catalog holds filter option tables scraped from the site, with full names
+type Catalog struct {
+	UpdatedAt time.Time         `json:"updated_at"`
+	Owners    map[string]int    `json:"owners"`
+	Hosts     map[string]int    `json:"hosts"`
+	DNS       map[string]int    `json:"dns"`
+	Countries map[string]string `json:"countries"`
+}
+
+// NewCatalog returns an empty catalog
+func NewCatalog() *Catalog {
+	return &Catalog{
+		Owners:    make(map[string]int),
+		Hosts:     make(map[string]int),
+		DNS:       make(map[string]int),
+		Countries: make(map[string]string),
+	}
+}
+
+// Add stores the entries of a catalog page and returns how many were new
+func (c *Catalog) Add(kind string, entries map[string]string) int {
+	added := 0
+	for name, id := range entries {
+		switch kind {
+		case CatalogCountries:
+			if _, ok := c.Countries[name]; !ok {
+				added++
+			}
+			c.Countries[name] = id
+		default:
+			n, err := strconv.Atoi(id)
+			if err != nil {
+				continue
+			}
+			table := c.idTable(kind)
+			if _, ok := table[name]; !ok {
+				added++
+			}
+			table[name] = n
+		}
+	}
+	return added
+}
+
+// idTable returns the numeric table of the given kind
+func (c *Catalog) idTable(kind string) map[string]int {
+	switch kind {
+	case CatalogOwners:
+		return c.Owners
+	case CatalogHosts:
+		return c.Hosts
+	default:
+		return c.DNS
+	}
+}
+
+// LoadCatalog reads a catalog saved with Save
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCatalog()
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("invalid catalog %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Save writes the catalog to path, replacing it atomically
+func (c *Catalog) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error saving catalog: %w", err)
+		}
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error saving catalog: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error saving catalog: %w", err)
+	}
+	return nil
+}
+
+// UseCatalog replaces the built-in Owners, Hosts, DNS and Countries tables
+// with the non-empty tables of c. Call it before resolving any names.
+func UseCatalog(c *Catalog) {
+	if len(c.Owners) > 0 {
+		Owners = c.Owners
+	}
+	if len(c.Hosts) > 0 {
+		Hosts = c.Hosts
+	}
+	if len(c.DNS) > 0 {
+		DNS = c.DNS
+	}
+	if len(c.Countries) > 0 {
+		Countries = c.Countries
+	}
+}
+
+// FetchCatalogPage fetches one page of the site's listing of the given kind
+// and returns its options, name to ID. An empty result with a nil error means
+// the listing has no more pages. Errors are those of FetchPage.
+func (c *Client) FetchCatalogPage(ctx context.Context, kind string, page int) (map[string]string, error) {
+	source, ok := catalogSources[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown catalog kind '%s'", kind)
+	}
+	if source.selectName != "" && page > 1 {
+		return nil, nil
+	}
+
+	resp, err := c.Post(ctx, c.BaseURL()+fmt.Sprintf(source.pathTemplate, page), requestData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlContent := string(body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &UnexpectedResponseError{StatusCode: resp.StatusCode, Body: htmlContent}
+	}
+
+	var entries []catalogEntry
+	if source.selectName != "" {
+		entries = extractSelectEntries(htmlContent, source.selectName)
+	} else {
+		entries = extractLinkEntries(htmlContent, source.linkPrefix)
+	}
+
+	if len(entries) == 0 {
+		if isCookieExpired(htmlContent) {
+			return nil, ErrVerificationRequired
+		} else if isIPLimitExceeded(htmlContent) {
+			return nil, ErrRateLimited
+		} else if source.selectName != "" || !hasTable(htmlContent) {
+			return nil, &UnexpectedResponseError{StatusCode: resp.StatusCode, Body: htmlContent}
+		}
+	}
+
+	options := make(map[string]string, len(entries))
+	for _, e := range entries {
+		options[e.name] = e.id
+	}
+	return options, nil
+}
+
+// Reset empties the table of the given kind
+func (c *Catalog) Reset(kind string) {
+	if kind == CatalogCountries {
+		c.Countries = make(map[string]string)
+		return
+	}
+	table := c.idTable(kind)
+	for name := range table {
+		delete(table, name)
+	}
+}
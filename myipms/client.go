@@ -0,0 +1,275 @@
+// Package myipms is a client for the myip.ms sites listings. It builds queries
+// from filters, iterates over their pages and parses the rows into Sites.
+package myipms
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the address of the myip.ms site
+const DefaultBaseURL = "https://myip.ms"
+
+// requestData holds the common POST data for requests
+var requestData = url.Values{
+	"getpage": []string{"yes"},
+	"lang":    []string{"en"},
+}
+
+// DefaultCookies seed the cookie jar when no session file is loaded
+var DefaultCookies = map[string]string{
+	"PHPSESSID":           "ae6doi5fo94hv5k2ouqmopd47k",
+	"s2_csrf_cookie_name": "cf0b4574d2c27713afd4b26879597e5d",
+	"s2_theme_ui":         "red",
+	"s2_uGoo":             "w6a162dd67b1968e6349944bcff010fdd63ee724",
+	"s2_uLang":            "en",
+	"sh":                  "72",
+	"sw":                  "95.4",
+}
+
+// DefaultHeaders are sent with every request. Origin and Referer are added
+// from the client's base URL.
+var DefaultHeaders = map[string]string{
+	"Content-Type":     "application/x-www-form-urlencoded; charset=UTF-8",
+	"X-Requested-With": "XMLHttpRequest",
+	"User-Agent":       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36",
+	"Accept":           "*/*",
+}
+
+// ClientOptions configures a Client
+type ClientOptions struct {
+	// BaseURL is the site to scrape, DefaultBaseURL if empty
+	BaseURL string
+
+	// ProxyURL is an optional http, https or socks5 proxy
+	ProxyURL  string
+	ProxyUser string
+	ProxyPass string
+
+	// RequestInterval is the minimum time between the start of two requests,
+	// shared by every goroutine using the client. Zero disables the limit.
+	RequestInterval time.Duration
+
+	// SessionFile persists the cookie jar across runs. It is loaded by
+	// NewClient when it exists, ignored when corrupt (see SessionError), and
+	// written by SaveSession.
+	SessionFile string
+}
+
+// Client is an HTTP client with the headers and cookies myip.ms expects.
+// Cookies live in a jar that records every Set-Cookie from the site.
+type Client struct {
+	client      *http.Client
+	baseURL     *url.URL
+	headers     map[string]string
+	jar         http.CookieJar
+	sessionFile string
+
+	// sessionSavedAt is when the loaded session was saved
+	sessionSavedAt time.Time
+	// sessionErr is why a corrupt session file was ignored
+	sessionErr error
+
+	interval time.Duration
+	mu       sync.Mutex
+	nextSlot time.Time
+}
+
+// NewClient creates a new client with default cookies and optional proxy
+func NewClient(opts ClientOptions) (*Client, error) {
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL '%s': must be an http or https URL", opts.BaseURL)
+	}
+
+	headers := make(map[string]string, len(DefaultHeaders)+2)
+	for key, value := range DefaultHeaders {
+		headers[key] = value
+	}
+	headers["Origin"] = baseURL
+	headers["Referer"] = baseURL + "/browse/sites/1"
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+	}
+
+	if opts.ProxyURL != "" {
+		proxyURLParsed, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL '%s': %v", opts.ProxyURL, err)
+		}
+		if opts.ProxyUser != "" && opts.ProxyPass != "" {
+			proxyURLParsed.User = url.UserPassword(opts.ProxyUser, opts.ProxyPass)
+		}
+		transport.Proxy = http.ProxyURL(proxyURLParsed)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+			Jar:       jar,
+		},
+		baseURL:     base,
+		headers:     headers,
+		jar:         jar,
+		sessionFile: opts.SessionFile,
+		interval:    opts.RequestInterval,
+	}
+
+	loaded, err := c.loadSession()
+	if err != nil {
+		return nil, err
+	}
+	if !loaded {
+		var cookies []*http.Cookie
+		for name, value := range DefaultCookies {
+			cookies = append(cookies, &http.Cookie{Name: name, Value: value})
+		}
+		c.SetCookies(cookies)
+	}
+
+	return c, nil
+}
+
+// BaseURL returns the site the client scrapes
+func (c *Client) BaseURL() string {
+	return c.baseURL.String()
+}
+
+// Cookies returns the cookies the client currently sends to the site
+func (c *Client) Cookies() []*http.Cookie {
+	return c.jar.Cookies(c.baseURL)
+}
+
+// SetCookies stores cookies for the site, replacing any with the same name
+func (c *Client) SetCookies(cookies []*http.Cookie) {
+	c.jar.SetCookies(c.baseURL, cookies)
+}
+
+// Post performs a POST request with the configured headers and cookies
+func (c *Client) Post(ctx context.Context, url string, data url.Values) (*http.Response, error) {
+	return c.makeRequest(ctx, "POST", url, data)
+}
+
+// Get performs a GET request with the configured headers and cookies
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	return c.makeRequest(ctx, "GET", url, nil)
+}
+
+// makeRequest performs HTTP requests with the configured headers and the jar's cookies.
+// The request is aborted when ctx is cancelled.
+func (c *Client) makeRequest(ctx context.Context, method, url string, data url.Values) (*http.Response, error) {
+	var req *http.Request
+	var err error
+
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if method == "POST" && data != nil {
+		req, err = http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(data.Encode()))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	return c.client.Do(req)
+}
+
+// wait blocks until the request interval allows another request
+func (c *Client) wait(ctx context.Context) error {
+	if c.interval <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	slot := c.nextSlot
+	if slot.Before(now) {
+		slot = now
+	}
+	c.nextSlot = slot.Add(c.interval)
+	c.mu.Unlock()
+
+	timer := time.NewTimer(slot.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NextRequest returns the earliest time the request interval allows another
+// request to start. It is zero when the client has no request interval.
+func (c *Client) NextRequest() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nextSlot
+}
+
+// FetchPage fetches a single page of the query and returns the sites found.
+// An empty result with a nil error means the listing has no more pages.
+func (c *Client) FetchPage(ctx context.Context, q *Query, page int) ([]Site, error) {
+	resp, err := c.Post(ctx, c.BaseURL()+q.PagePath(page), requestData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlContent := string(body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &UnexpectedResponseError{StatusCode: resp.StatusCode, Body: htmlContent}
+	}
+
+	sites := extractSites(htmlContent)
+
+	if len(sites) == 0 {
+		if isCookieExpired(htmlContent) {
+			return nil, ErrVerificationRequired
+		} else if isIPLimitExceeded(htmlContent) {
+			return nil, ErrRateLimited
+		} else if !hasTable(htmlContent) {
+			return nil, &UnexpectedResponseError{StatusCode: resp.StatusCode, Body: htmlContent}
+		}
+	}
+
+	return sites, nil
+}
@@ -0,0 +1,401 @@
+package myipms_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+	"github.com/ayanrajpoot10/myipms-scraper/myipms/myipmstest"
+)
+
+// newTestClient returns a client pointed at a fake server
+func newTestClient(t *testing.T, srv *myipmstest.Server) *myipms.Client {
+	t.Helper()
+	client, err := myipms.NewClient(myipms.ClientOptions{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+// solve answers the captcha, first with a wrong answer to exercise the retry path
+func solve(t *testing.T, ctx context.Context, client *myipms.Client) {
+	t.Helper()
+
+	captcha, err := client.RequestCaptcha(ctx)
+	if err != nil {
+		t.Fatalf("RequestCaptcha: %v", err)
+	}
+
+	next, err := client.SubmitCaptcha(ctx, captcha, "wrong")
+	if !errors.Is(err, myipms.ErrCaptchaRejected) || next == nil {
+		t.Fatalf("SubmitCaptcha(wrong) = %v, %v; want ErrCaptchaRejected with a new captcha", next, err)
+	}
+
+	if _, err := client.SubmitCaptcha(ctx, next, myipmstest.DefaultCaptchaAnswer); err != nil {
+		t.Fatalf("SubmitCaptcha(correct): %v", err)
+	}
+}
+
+// collect drains the iterator, solving captchas whenever verification is required
+func collect(t *testing.T, ctx context.Context, client *myipms.Client, pages *myipms.PageIterator) ([]myipms.Site, int) {
+	t.Helper()
+
+	var sites []myipms.Site
+	captchas := 0
+	for {
+		if pages.Next(ctx) {
+			sites = append(sites, pages.Page().Sites...)
+			continue
+		}
+
+		err := pages.Err()
+		if err == nil {
+			return sites, captchas
+		}
+		if !errors.Is(err, myipms.ErrVerificationRequired) {
+			t.Fatalf("Next: %v", err)
+		}
+		if captchas++; captchas > 10 {
+			t.Fatal("too many captchas")
+		}
+		solve(t, ctx, client)
+	}
+}
+
+func TestScrapeCaptchaResume(t *testing.T) {
+	ctx := context.Background()
+	srv := myipmstest.NewServer(myipmstest.Options{Sites: 230, PageSize: 50, VerifyAfter: 2})
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	query := myipms.NewQuery(&myipms.Filter{CountryCode: "USA", OwnerID: 615})
+
+	// First run stops after two pages, as if interrupted
+	pages := client.Pages(query, 1)
+	var first []myipms.Site
+	for i := 0; i < 2; i++ {
+		if !pages.Next(ctx) {
+			t.Fatalf("Next: %v", pages.Err())
+		}
+		first = append(first, pages.Page().Sites...)
+	}
+
+	// The resumed run hits verification straight away
+	resumed := client.Pages(query, pages.NextPage())
+	rest, captchas := collect(t, ctx, client, resumed)
+	if captchas == 0 {
+		t.Error("expected at least one captcha")
+	}
+
+	if got := append(first, rest...); !reflect.DeepEqual(got, srv.Sites()) {
+		t.Errorf("got %d sites, want all %d in order", len(got), len(srv.Sites()))
+	}
+
+	for _, path := range srv.Paths() {
+		if strings.HasPrefix(path, "/ajax_table/sites/") && !strings.HasSuffix(path, "/countryID/USA/own/615") && path != "/ajax_table/sites/1" {
+			t.Errorf("request path %q is missing the filters", path)
+		}
+	}
+}
+
+func TestConcurrentPagesStayOrdered(t *testing.T) {
+	ctx := context.Background()
+	srv := myipmstest.NewServer(myipmstest.Options{Sites: 500, PageSize: 20, VerifyAfter: 7})
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	pages := client.Pages(myipms.NewQuery(&myipms.Filter{}), 1)
+	pages.SetConcurrency(4)
+
+	sites, captchas := collect(t, ctx, client, pages)
+	if captchas == 0 {
+		t.Error("expected at least one captcha")
+	}
+	if !reflect.DeepEqual(sites, srv.Sites()) {
+		t.Errorf("got %d sites, want all %d in order", len(sites), len(srv.Sites()))
+	}
+}
+
+func TestConcurrentPagesStopAtLimit(t *testing.T) {
+	ctx := context.Background()
+	srv := myipmstest.NewServer(myipmstest.Options{Sites: 500, PageSize: 20})
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	pages := client.Pages(myipms.NewQuery(&myipms.Filter{}), 3)
+	pages.SetConcurrency(5)
+	pages.SetLimit(4)
+
+	sites, _ := collect(t, ctx, client, pages)
+	if want := srv.Sites()[40:80]; !reflect.DeepEqual(sites, want) {
+		t.Errorf("got %d sites, want the %d of pages 3 and 4", len(sites), len(want))
+	}
+
+	var listed []string
+	for _, path := range srv.Paths() {
+		if strings.HasPrefix(path, "/ajax_table/sites/") {
+			listed = append(listed, path)
+		}
+	}
+	sort.Strings(listed)
+	if want := []string{"/ajax_table/sites/3", "/ajax_table/sites/4"}; !reflect.DeepEqual(listed, want) {
+		t.Errorf("requested %q, want only pages 3 and 4", listed)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	srv := myipmstest.NewServer(myipmstest.Options{})
+	defer srv.Close()
+	srv.SetRateLimited(true)
+
+	pages := newTestClient(t, srv).Pages(myipms.NewQuery(&myipms.Filter{}), 1)
+	if pages.Next(context.Background()) {
+		t.Fatal("Next succeeded while rate limited")
+	}
+	if err := pages.Err(); !errors.Is(err, myipms.ErrRateLimited) {
+		t.Fatalf("Err() = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestUnexpectedResponse(t *testing.T) {
+	srv := myipmstest.NewServer(myipmstest.Options{})
+	defer srv.Close()
+
+	client, err := myipms.NewClient(myipms.ClientOptions{BaseURL: srv.URL + "/missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.FetchPage(context.Background(), myipms.NewQuery(&myipms.Filter{}), 1)
+	var unexpected *myipms.UnexpectedResponseError
+	if !errors.As(err, &unexpected) || !errors.Is(err, myipms.ErrUnexpectedResponse) {
+		t.Fatalf("FetchPage() error = %v, want *UnexpectedResponseError", err)
+	}
+	if unexpected.StatusCode != 404 {
+		t.Errorf("StatusCode = %d, want 404", unexpected.StatusCode)
+	}
+}
+
+func TestSessionSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	srv := myipmstest.NewServer(myipmstest.Options{})
+	defer srv.Close()
+	srv.RequireVerification()
+
+	sessionFile := filepath.Join(t.TempDir(), "session.json")
+	opts := myipms.ClientOptions{BaseURL: srv.URL, SessionFile: sessionFile}
+
+	client, err := myipms.NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solve(t, ctx, client)
+
+	verified := cookieValue(client, myipmstest.VerifiedCookie)
+	if verified == "" {
+		t.Fatal("Set-Cookie from the captcha response was not stored in the jar")
+	}
+	if err := client.SaveSession(); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+
+	restarted, err := myipms.NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cookieValue(restarted, myipmstest.VerifiedCookie); got != verified {
+		t.Errorf("restarted client has %s=%q, want %q", myipmstest.VerifiedCookie, got, verified)
+	}
+	if got := cookieValue(restarted, "PHPSESSID"); got != myipms.DefaultCookies["PHPSESSID"] {
+		t.Errorf("restarted client has PHPSESSID=%q, want the saved value", got)
+	}
+
+	other, err := myipms.NewClient(myipms.ClientOptions{SessionFile: sessionFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cookieValue(other, myipmstest.VerifiedCookie); got != "" {
+		t.Error("session saved for another base URL was loaded")
+	}
+
+	if restarted.SessionSavedAt().IsZero() || !client.SessionSavedAt().IsZero() {
+		t.Errorf("SessionSavedAt() = %v for the loaded session and %v for a new one; want only the first set",
+			restarted.SessionSavedAt(), client.SessionSavedAt())
+	}
+	if err := restarted.ClearSession(); err != nil {
+		t.Fatalf("ClearSession: %v", err)
+	}
+	cleared, err := myipms.NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cookieValue(cleared, myipmstest.VerifiedCookie); got != "" {
+		t.Error("client created after ClearSession still has the session's cookies")
+	}
+}
+
+func TestCorruptSessionFallsBack(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "session.json")
+	if err := os.WriteFile(sessionFile, []byte("{\"cookies\": [tru"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := myipms.NewClient(myipms.ClientOptions{SessionFile: sessionFile})
+	if err != nil {
+		t.Fatalf("NewClient with a corrupt session: %v", err)
+	}
+	if err := client.SessionError(); err == nil || !strings.Contains(err.Error(), sessionFile) {
+		t.Errorf("SessionError() = %v, want an error naming %s", err, sessionFile)
+	}
+	for name, value := range myipms.DefaultCookies {
+		if got := cookieValue(client, name); got != value {
+			t.Errorf("client has %s=%q, want the built-in %q", name, got, value)
+		}
+	}
+
+	if err := client.SaveSession(); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	saved, err := myipms.NewClient(myipms.ClientOptions{SessionFile: sessionFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saved.SessionError(); err != nil {
+		t.Errorf("session saved over a corrupt one does not load: %v", err)
+	}
+}
+
+// cookieValue returns the value of the named cookie in the client's jar
+func cookieValue(client *myipms.Client, name string) string {
+	for _, c := range client.Cookies() {
+		if c.Name == name {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func TestImportCookies(t *testing.T) {
+	srv := myipmstest.NewServer(myipmstest.Options{})
+	defer srv.Close()
+
+	exports := map[string]string{
+		"cookies.txt": "# Netscape HTTP Cookie File\n" +
+			"127.0.0.1\tFALSE\t/\tFALSE\t0\tPHPSESSID\tfrombrowser\n" +
+			"#HttpOnly_127.0.0.1\tFALSE\t/\tFALSE\t0\ts2_verified\tyes\n" +
+			".example.com\tTRUE\t/\tFALSE\t0\tother\tnope\n" +
+			"127.0.0.1\tFALSE\t/\tFALSE\t1\texpired\tnope\n",
+		"cookies.json": `[{"domain":"127.0.0.1","name":"PHPSESSID","value":"frombrowser","path":"/"},
+			{"domain":"127.0.0.1","name":"s2_verified","value":"yes","expirationDate":4102444800.5},
+			{"domain":".example.com","name":"other","value":"nope"}]`,
+		"state.json": `{"cookies":[{"domain":"127.0.0.1","name":"PHPSESSID","value":"frombrowser","expires":-1},
+			{"domain":"127.0.0.1","name":"s2_verified","value":"yes","expires":-1}],"origins":[]}`,
+	}
+
+	for name, content := range exports {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			client := newTestClient(t, srv)
+			missing, err := client.ImportCookies(path)
+			if err != nil {
+				t.Fatalf("ImportCookies: %v", err)
+			}
+			if len(missing) != 0 {
+				t.Errorf("missing = %v, want none", missing)
+			}
+			if got := cookieValue(client, "PHPSESSID"); got != "frombrowser" {
+				t.Errorf("PHPSESSID = %q, want the imported value", got)
+			}
+			if got := cookieValue(client, "s2_verified"); got != "yes" {
+				t.Errorf("s2_verified = %q, want the imported value", got)
+			}
+			for _, skipped := range []string{"other", "expired"} {
+				if cookieValue(client, skipped) != "" {
+					t.Errorf("cookie %q should not have been imported", skipped)
+				}
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "partial.txt")
+	os.WriteFile(path, []byte("127.0.0.1\tFALSE\t/\tFALSE\t0\ts2_verified\tyes\n"), 0600)
+	missing, err := newTestClient(t, srv).ImportCookies(path)
+	if err != nil || !reflect.DeepEqual(missing, []string{"PHPSESSID"}) {
+		t.Errorf("ImportCookies(partial) = %v, %v; want PHPSESSID missing", missing, err)
+	}
+}
+
+func TestUpdateCatalog(t *testing.T) {
+	ctx := context.Background()
+	srv := myipmstest.NewServer(myipmstest.Options{PageSize: 2, VerifyAfter: 3})
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	catalog := myipms.NewCatalog()
+	for _, kind := range myipms.CatalogKinds {
+		for page, captchas := 1, 0; ; {
+			entries, err := client.FetchCatalogPage(ctx, kind, page)
+			if errors.Is(err, myipms.ErrVerificationRequired) {
+				if captchas++; captchas > 10 {
+					t.Fatal("too many captchas")
+				}
+				solve(t, ctx, client)
+				continue
+			}
+			if err != nil {
+				t.Fatalf("FetchCatalogPage(%s, %d): %v", kind, page, err)
+			}
+			if catalog.Add(kind, entries) == 0 {
+				break
+			}
+			page++
+		}
+	}
+
+	ids := func(entries []myipmstest.CatalogEntry) map[string]int {
+		m := make(map[string]int)
+		for _, e := range entries {
+			n, _ := strconv.Atoi(e.ID)
+			m[e.Name] = n
+		}
+		return m
+	}
+	countries := make(map[string]string)
+	for _, e := range myipmstest.FakeCountries {
+		countries[e.Name] = e.ID
+	}
+
+	for name, tc := range map[string]struct{ got, want interface{} }{
+		"owners":    {catalog.Owners, ids(myipmstest.FakeOwners)},
+		"hosts":     {catalog.Hosts, ids(myipmstest.FakeHosts)},
+		"dns":       {catalog.DNS, ids(myipmstest.FakeDNS)},
+		"countries": {catalog.Countries, countries},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Errorf("%s = %v, want %v", name, tc.got, tc.want)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "options.json")
+	if err := catalog.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := myipms.LoadCatalog(path)
+	if err != nil {
+		t.Fatalf("LoadCatalog: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, catalog) {
+		t.Errorf("LoadCatalog = %+v, want %+v", loaded, catalog)
+	}
+}
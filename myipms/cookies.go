@@ -0,0 +1,185 @@
+package myipms
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequiredCookies are the cookies the site needs to recognise a verified session
+var RequiredCookies = []string{"PHPSESSID"}
+
+// ImportCookies loads cookies exported from a browser into the client's jar.
+// The file may be a Netscape cookies.txt or a JSON export, either an array of
+// cookies or an object with a "cookies" array. Only cookies for the client's
+// site are kept. It returns the names in RequiredCookies the file did not have.
+func (c *Client) ImportCookies(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cookies: %w", err)
+	}
+
+	cookies, err := ParseCookies(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cookies file %s: %w", path, err)
+	}
+
+	host := c.baseURL.Hostname()
+	now := time.Now()
+	var kept []*http.Cookie
+	names := make(map[string]bool)
+	for _, cookie := range cookies {
+		if !domainMatch(host, cookie.Domain) || (!cookie.Expires.IsZero() && cookie.Expires.Before(now)) {
+			continue
+		}
+		kept = append(kept, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		names[cookie.Name] = true
+	}
+
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("no cookies for %s in %s", host, path)
+	}
+	c.SetCookies(kept)
+
+	var missing []string
+	for _, name := range RequiredCookies {
+		if !names[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// ParseCookies parses a Netscape cookies.txt or a JSON cookie export
+func ParseCookies(data []byte) ([]*http.Cookie, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return parseJSONCookies(trimmed)
+	}
+	return parseNetscapeCookies(data)
+}
+
+// parseNetscapeCookies parses the tab separated cookies.txt format:
+// domain, include subdomains, path, secure, expiry, name, value
+func parseNetscapeCookies(data []byte) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		httpOnly := strings.HasPrefix(text, "#HttpOnly_")
+		if httpOnly {
+			text = strings.TrimPrefix(text, "#HttpOnly_")
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) == 6 {
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab separated fields, got %d", line, len(fields))
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry '%s'", line, fields[4])
+		}
+
+		cookie := &http.Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("no cookies found")
+	}
+	return cookies, nil
+}
+
+// jsonCookie covers the fields used by common browser extensions
+// (expirationDate) and by Playwright and Puppeteer (expires)
+type jsonCookie struct {
+	Name           string   `json:"name"`
+	Value          string   `json:"value"`
+	Domain         string   `json:"domain"`
+	Path           string   `json:"path"`
+	Secure         bool     `json:"secure"`
+	HTTPOnly       bool     `json:"httpOnly"`
+	ExpirationDate *float64 `json:"expirationDate"`
+	Expires        *float64 `json:"expires"`
+}
+
+// parseJSONCookies parses an array of cookies or an object with a "cookies" array
+func parseJSONCookies(data []byte) ([]*http.Cookie, error) {
+	var list []jsonCookie
+	if data[0] == '{' {
+		var wrapped struct {
+			Cookies []jsonCookie `json:"cookies"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, err
+		}
+		list = wrapped.Cookies
+	} else if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	var cookies []*http.Cookie
+	for _, jc := range list {
+		if jc.Name == "" {
+			continue
+		}
+
+		cookie := &http.Cookie{
+			Name:     jc.Name,
+			Value:    jc.Value,
+			Domain:   jc.Domain,
+			Path:     jc.Path,
+			Secure:   jc.Secure,
+			HttpOnly: jc.HTTPOnly,
+		}
+
+		expiry := jc.ExpirationDate
+		if expiry == nil {
+			expiry = jc.Expires
+		}
+		if expiry != nil && *expiry > 0 {
+			cookie.Expires = time.Unix(int64(*expiry), 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("no cookies found")
+	}
+	return cookies, nil
+}
+
+// domainMatch reports whether a cookie for domain is sent to host
+func domainMatch(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	host = strings.ToLower(host)
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
@@ -0,0 +1,197 @@
+package myipms
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// alpha2Codes maps ISO 3166-1 alpha-2 country codes to alpha-3 codes
+var alpha2Codes = map[string]string{
+	"AD": "AND", "AE": "ARE", "AF": "AFG", "AG": "ATG", "AI": "AIA", "AL": "ALB", "AM": "ARM", "AN": "ANT",
+	"AO": "AGO", "AQ": "ATA", "AR": "ARG", "AS": "ASM", "AT": "AUT", "AU": "AUS", "AW": "ABW", "AX": "ALA",
+	"AZ": "AZE", "BA": "BIH", "BB": "BRB", "BD": "BGD", "BE": "BEL", "BF": "BFA", "BG": "BGR", "BH": "BHR",
+	"BI": "BDI", "BJ": "BEN", "BL": "BLM", "BM": "BMU", "BN": "BRN", "BO": "BOL", "BQ": "BES", "BR": "BRA",
+	"BS": "BHS", "BT": "BTN", "BV": "BVT", "BW": "BWA", "BY": "BLR", "BZ": "BLZ", "CA": "CAN", "CC": "CCK",
+	"CD": "COD", "CF": "CAF", "CG": "COG", "CH": "CHE", "CI": "CIV", "CK": "COK", "CL": "CHL", "CM": "CMR",
+	"CN": "CHN", "CO": "COL", "CR": "CRI", "CU": "CUB", "CV": "CPV", "CW": "CUW", "CX": "CXR", "CY": "CYP",
+	"CZ": "CZE", "DE": "DEU", "DJ": "DJI", "DK": "DNK", "DM": "DMA", "DO": "DOM", "DZ": "DZA", "EC": "ECU",
+	"EE": "EST", "EG": "EGY", "EH": "ESH", "ER": "ERI", "ES": "ESP", "ET": "ETH", "EU": "EUR", "FI": "FIN",
+	"FJ": "FJI", "FK": "FLK", "FM": "FSM", "FO": "FRO", "FR": "FRA", "GA": "GAB", "GB": "GBR", "GD": "GRD",
+	"GE": "GEO", "GF": "GUF", "GG": "GGY", "GH": "GHA", "GI": "GIB", "GL": "GRL", "GM": "GMB", "GN": "GIN",
+	"GP": "GLP", "GQ": "GNQ", "GR": "GRC", "GS": "SGS", "GT": "GTM", "GU": "GUM", "GW": "GNB", "GY": "GUY",
+	"HK": "HKG", "HM": "HMD", "HN": "HND", "HR": "HRV", "HT": "HTI", "HU": "HUN", "ID": "IDN", "IE": "IRL",
+	"IL": "ISR", "IM": "IMN", "IN": "IND", "IO": "IOT", "IQ": "IRQ", "IR": "IRN", "IS": "ISL", "IT": "ITA",
+	"JE": "JEY", "JM": "JAM", "JO": "JOR", "JP": "JPN", "KE": "KEN", "KG": "KGZ", "KH": "KHM", "KI": "KIR",
+	"KM": "COM", "KN": "KNA", "KP": "PRK", "KR": "KOR", "KW": "KWT", "KY": "CYM", "KZ": "KAZ", "LA": "LAO",
+	"LB": "LBN", "LC": "LCA", "LI": "LIE", "LK": "LKA", "LR": "LBR", "LS": "LSO", "LT": "LTU", "LU": "LUX",
+	"LV": "LVA", "LY": "LBY", "MA": "MAR", "MC": "MCO", "MD": "MDA", "ME": "MNE", "MF": "MAF", "MG": "MDG",
+	"MH": "MHL", "MK": "MKD", "ML": "MLI", "MM": "MMR", "MN": "MNG", "MO": "MAC", "MP": "MNP", "MQ": "MTQ",
+	"MR": "MRT", "MS": "MSR", "MT": "MLT", "MU": "MUS", "MV": "MDV", "MW": "MWI", "MX": "MEX", "MY": "MYS",
+	"MZ": "MOZ", "NA": "NAM", "NC": "NCL", "NE": "NER", "NF": "NFK", "NG": "NGA", "NI": "NIC", "NL": "NLD",
+	"NO": "NOR", "NP": "NPL", "NR": "NRU", "NU": "NIU", "NZ": "NZL", "OM": "OMN", "PA": "PAN", "PE": "PER",
+	"PF": "PYF", "PG": "PNG", "PH": "PHL", "PK": "PAK", "PL": "POL", "PM": "SPM", "PN": "PCN", "PR": "PRI",
+	"PS": "PSE", "PT": "PRT", "PW": "PLW", "PY": "PRY", "QA": "QAT", "RE": "REU", "RO": "ROU", "RS": "SRB",
+	"RU": "RUS", "RW": "RWA", "SA": "SAU", "SB": "SLB", "SC": "SYC", "SD": "SDN", "SE": "SWE", "SG": "SGP",
+	"SH": "SHN", "SI": "SVN", "SJ": "SJM", "SK": "SVK", "SL": "SLE", "SM": "SMR", "SN": "SEN", "SO": "SOM",
+	"SR": "SUR", "SS": "SSD", "ST": "STP", "SV": "SLV", "SX": "SXM", "SY": "SYR", "SZ": "SWZ", "TC": "TCA",
+	"TD": "TCD", "TF": "ATF", "TG": "TGO", "TH": "THA", "TJ": "TJK", "TK": "TKL", "TL": "TLS", "TM": "TKM",
+	"TN": "TUN", "TO": "TON", "TR": "TUR", "TT": "TTO", "TV": "TUV", "TW": "TWN", "TZ": "TZA", "UA": "UKR",
+	"UG": "UGA", "UM": "UMI", "US": "USA", "UY": "URY", "UZ": "UZB", "VA": "VAT", "VC": "VCT", "VE": "VEN",
+	"VG": "VGB", "VI": "VIR", "VN": "VNM", "VU": "VUT", "WF": "WLF", "WS": "WSM", "XK": "XKX", "YE": "YEM",
+	"YT": "MYT", "ZA": "ZAF", "ZM": "ZMB", "ZW": "ZWE",
+}
+
+// siteCodes maps ISO 3166-1 alpha-3 codes to the older codes myip.ms still
+// uses for the same country
+var siteCodes = map[string]string{
+	"ROU": "ROM",
+	"TLS": "TMP",
+}
+
+// countryAliases maps common names that differ from the site's to the
+// alpha-3 code they stand for
+var countryAliases = map[string]string{
+	"america":                  "USA",
+	"united states":            "USA",
+	"united states of america": "USA",
+	"uk":                       "GBR",
+	"britain":                  "GBR",
+	"great britain":            "GBR",
+	"england":                  "GBR",
+	"republic of korea":        "KOR",
+	"dprk":                     "PRK",
+	"uae":                      "ARE",
+	"holland":                  "NLD",
+	"the netherlands":          "NLD",
+	"czechia":                  "CZE",
+	"russian federation":       "RUS",
+	"ivory coast":              "CIV",
+	"burma":                    "MMR",
+	"macau":                    "MAC",
+	"timor-leste":              "TLS",
+	"eswatini":                 "SWZ",
+	"kazakhstan":               "KAZ",
+	"cabo verde":               "CPV",
+	"turkiye":                  "TUR",
+	"macedonia":                "MKD",
+	"viet nam":                 "VNM",
+	"drc":                      "COD",
+}
+
+// FindCountry returns the names in Countries that input may refer to, sorted.
+// It accepts a country name in any casing, an ISO 3166-1 alpha-2 or alpha-3
+// code, a common alias such as "UK", or whole words of a name, such as
+// "Vatican". One name means input resolves to that country, several that it
+// is ambiguous (e.g. "Korea"), and none that it is unknown.
+func FindCountry(input string) []string {
+	input = strings.TrimSpace(input)
+	if _, ok := Countries[input]; ok {
+		return []string{input}
+	}
+
+	key := foldCountry(input)
+	var names []string
+
+	// The country's name, in any casing
+	for name := range Countries {
+		if foldCountry(name) == key {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		return sortedNames(names)
+	}
+
+	// Aliases and codes
+	code, ok := countryAliases[key]
+	if !ok {
+		switch upper := strings.ToUpper(input); len(input) {
+		case 2:
+			code = alpha2Codes[upper]
+		case 3:
+			code = upper
+		}
+	}
+	if names = namesForCode(code); len(names) > 0 {
+		return names
+	}
+
+	// Names with the input as a whole word or run of words
+	for name := range Countries {
+		if containsWords(foldCountry(name), key) {
+			names = append(names, name)
+		}
+	}
+	return sortedNames(names)
+}
+
+// namesForCode returns the names in Countries with the alpha-3 code, or with
+// the older code the site uses for it, sorted
+func namesForCode(code string) []string {
+	if code == "" {
+		return nil
+	}
+	site := siteCodes[code]
+
+	var names []string
+	for name, c := range Countries {
+		if c == code || c == site {
+			names = append(names, name)
+		}
+	}
+	return sortedNames(names)
+}
+
+// countryForCode returns the name in Countries with the alpha-3 code, the
+// first in sorted order if several have it, or "" if none has
+func countryForCode(code string) string {
+	if names := namesForCode(code); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// foldCountry normalises a country name for comparison: lower case, accents
+// and curly apostrophes replaced, and spaces collapsed
+func foldCountry(s string) string {
+	s = countryFolder.Replace(strings.ToLower(s))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// countryFolder replaces the accents and apostrophes found in country names
+var countryFolder = strings.NewReplacer(
+	"’", "'", "é", "e", "è", "e", "ô", "o", "ç", "c", "ã", "a", "í", "i", "ü", "u",
+)
+
+// containsWords reports whether words occurs in s starting and ending at word
+// boundaries
+func containsWords(s, words string) bool {
+	if words == "" {
+		return false
+	}
+	boundary := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }
+
+	for i := 0; ; {
+		j := strings.Index(s[i:], words)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(words)
+		prev, _ := utf8.DecodeLastRuneInString(s[:start])
+		next, _ := utf8.DecodeRuneInString(s[end:])
+		before := start == 0 || boundary(prev)
+		after := end == len(s) || boundary(next)
+		if before && after {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// sortedNames sorts names in place and returns them
+func sortedNames(names []string) []string {
+	sort.Strings(names)
+	return names
+}
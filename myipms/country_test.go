@@ -0,0 +1,72 @@
+package myipms
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindCountry(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "Germany", want: []string{"Germany"}},
+		{input: "  gERMANY ", want: []string{"Germany"}},
+		{input: "de", want: []string{"Germany"}},
+		{input: "DEU", want: []string{"Germany"}},
+		{input: "usa", want: []string{"USA"}},
+		{input: "United States", want: []string{"USA"}},
+		{input: "UK", want: []string{"United Kingdom"}},
+		{input: "GB", want: []string{"United Kingdom"}},
+		{input: "Romania", want: []string{"Romania"}},
+		{input: "ROU", want: []string{"Romania"}},
+		{input: "cote d'ivoire", want: []string{"Côte d’Ivoire"}},
+		{input: "Vatican", want: []string{"Vatican (Holy See)"}},
+		{input: "korea", want: []string{"North Korea", "South Korea"}},
+		{input: "virgin islands", want: []string{"Virgin Islands, British", "Virgin Islands, U.S."}},
+		{input: "Guinea", want: []string{"Guinea"}},
+		{input: "Atlantis"},
+		{input: "QQ"},
+		{input: "ZZZ"},
+		{input: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := FindCountry(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindCountry(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindCountryUsesCatalog(t *testing.T) {
+	saved := Countries
+	defer func() { Countries = saved }()
+
+	// Tables fetched from the site may name countries differently
+	UseCatalog(&Catalog{Countries: map[string]string{
+		"United States":  "USA",
+		"Korea, South":   "KOR",
+		"Korea, North":   "PRK",
+		"Timor-Leste":    "TLS",
+		"United Kingdom": "GBR",
+	}})
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "us", want: []string{"United States"}},
+		{input: "usa", want: []string{"United States"}},
+		{input: "timor-leste", want: []string{"Timor-Leste"}},
+		{input: "TL", want: []string{"Timor-Leste"}},
+		{input: "korea", want: []string{"Korea, North", "Korea, South"}},
+		{input: "KR", want: []string{"Korea, South"}},
+	}
+	for _, tt := range tests {
+		if got := FindCountry(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindCountry(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
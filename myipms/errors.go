@@ -0,0 +1,43 @@
+package myipms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrVerificationRequired is returned when myip.ms answers with its Human Verification form
+	ErrVerificationRequired = errors.New("cookies expired - human verification required")
+
+	// ErrRateLimited is returned when myip.ms reports that the IP exceeded its page visit limit
+	ErrRateLimited = errors.New("IP limit exceeded - you have exceeded page visit limit")
+
+	// ErrUnexpectedResponse matches every *UnexpectedResponseError
+	ErrUnexpectedResponse = errors.New("unexpected response")
+
+	// ErrNoCaptcha is returned when a Human Verification form has no captcha image or token
+	ErrNoCaptcha = errors.New("no captcha image URL or token found")
+
+	// ErrCaptchaRejected is returned when myip.ms does not accept a captcha answer
+	ErrCaptchaRejected = errors.New("incorrect captcha")
+)
+
+// UnexpectedResponseError is returned when a response is neither a sites table
+// nor one of the known error pages. It carries the response for inspection.
+type UnexpectedResponseError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *UnexpectedResponseError) Error() string {
+	if e.StatusCode != http.StatusOK {
+		return fmt.Sprintf("%v: HTTP %d", ErrUnexpectedResponse, e.StatusCode)
+	}
+	return fmt.Sprintf("%v: no sites table found", ErrUnexpectedResponse)
+}
+
+// Is reports whether target is ErrUnexpectedResponse
+func (e *UnexpectedResponseError) Is(target error) bool {
+	return target == ErrUnexpectedResponse
+}
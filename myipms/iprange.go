@@ -0,0 +1,50 @@
+package myipms
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// CheckIPRange validates an IP range for a Filter and returns its endpoints
+// normalised: IPv4 and IPv4-mapped IPv6 addresses in their 4-byte form, IPv6
+// addresses in 16 bytes. Both endpoints must be of one address family and from
+// must not be above to.
+//
+// The size of the range is not checked: the widest range the site searches is
+// not documented and has not been confirmed for either address family.
+func CheckIPRange(from, to net.IP) (net.IP, net.IP, error) {
+	if from == nil || to == nil {
+		return nil, nil, fmt.Errorf("IP range needs both a start and an end address")
+	}
+
+	from, to = normaliseIP(from), normaliseIP(to)
+	if len(from) != len(to) {
+		return nil, nil, fmt.Errorf("IP range %s-%s mixes IPv4 and IPv6 addresses; use one address family", from, to)
+	}
+	if bytes.Compare(from, to) > 0 {
+		return nil, nil, fmt.Errorf("IP range %s-%s starts above its end; did you mean %s-%s?", from, to, to, from)
+	}
+
+	return from, to, nil
+}
+
+// normaliseIP returns IPv4 and IPv4-mapped addresses in 4 bytes and every
+// other address in 16
+func normaliseIP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip.To16()
+}
+
+// formatIP formats a filter's IP address for a URL path: IPv4-mapped IPv6
+// addresses as IPv4, and IPv6 addresses in their canonical compressed form.
+// Addresses that do not parse are left as they are.
+func formatIP(s string) string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return s
+	}
+	return normaliseIP(ip).String()
+}
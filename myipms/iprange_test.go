@@ -0,0 +1,66 @@
+package myipms
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestCheckIPRange(t *testing.T) {
+	tests := []struct {
+		from, to         string
+		wantFrom, wantTo string
+		wantErr          string
+	}{
+		{from: "10.0.0.0", to: "10.255.255.255", wantFrom: "10.0.0.0", wantTo: "10.255.255.255"},
+		{from: "10.0.0.5", to: "10.0.0.5", wantFrom: "10.0.0.5", wantTo: "10.0.0.5"},
+		{from: "::ffff:192.0.2.0", to: "192.0.2.255", wantFrom: "192.0.2.0", wantTo: "192.0.2.255"},
+		{from: "2001:db8::", to: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", wantFrom: "2001:db8::", wantTo: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{from: "10.0.0.9", to: "10.0.0.1", wantErr: "starts above its end"},
+		{from: "2001:db8::2", to: "2001:db8::1", wantErr: "starts above its end"},
+		{from: "10.0.0.1", to: "2001:db8::1", wantErr: "mixes IPv4 and IPv6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"-"+tt.to, func(t *testing.T) {
+			from, to, err := CheckIPRange(net.ParseIP(tt.from), net.ParseIP(tt.to))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CheckIPRange() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckIPRange() error = %v", err)
+			}
+			if from.String() != tt.wantFrom || to.String() != tt.wantTo {
+				t.Errorf("CheckIPRange() = %s-%s, want %s-%s", from, to, tt.wantFrom, tt.wantTo)
+			}
+			if strings.Contains(tt.wantFrom, ".") && len(from) != net.IPv4len {
+				t.Errorf("IPv4 start has %d bytes, want %d", len(from), net.IPv4len)
+			}
+		})
+	}
+
+	if _, _, err := CheckIPRange(nil, net.ParseIP("10.0.0.1")); err == nil {
+		t.Error("CheckIPRange() accepted a range without a start")
+	}
+}
+
+func TestQueryIPRangePath(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"10.0.0.0", "10.0.0.255", "/ajax_table/sites/2/ipID/10.0.0.0/ipIDii/10.0.0.255"},
+		{"::ffff:10.0.0.0", "::ffff:10.0.0.255", "/ajax_table/sites/2/ipID/10.0.0.0/ipIDii/10.0.0.255"},
+		{"2001:DB8:0:0::1", "2001:db8::ff", "/ajax_table/sites/2/ipID/2001:db8::1/ipIDii/2001:db8::ff"},
+	}
+
+	for _, tt := range tests {
+		q := NewQuery(&Filter{IPFrom: tt.from, IPTo: tt.to})
+		if got := q.PagePath(2); got != tt.want {
+			t.Errorf("PagePath(2) for %s-%s = %s, want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package myipms
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Page holds the sites found on a single page of a query
+type Page struct {
+	Number int
+	Sites  []Site
+}
+
+// PageIterator walks the pages of a query in order.
+//
+// Next returns false both at the end of the listing and on error; check Err
+// to tell them apart. After an error Next may be called again to retry the
+// same page, e.g. once ErrVerificationRequired has been dealt with.
+type PageIterator struct {
+	client *Client
+	query  *Query
+	next   int
+	page   Page
+	err    error
+	done   bool
+
+	// concurrency is the number of pages fetched in parallel
+	concurrency int
+	// limit is the last page to fetch, or 0 for no limit
+	limit int
+	// fetched holds pages fetched ahead of next, keyed by page number
+	fetched map[int][]Site
+}
+
+// Pages returns an iterator over the pages of q, starting at page start
+func (c *Client) Pages(q *Query, start int) *PageIterator {
+	return &PageIterator{
+		client:      c,
+		query:       q,
+		next:        start,
+		concurrency: 1,
+		fetched:     make(map[int][]Site),
+	}
+}
+
+// SetConcurrency sets how many pages are fetched in parallel. Pages are still
+// returned in order. When any fetch sees ErrVerificationRequired or
+// ErrRateLimited, the other fetches of the batch are cancelled so that every
+// worker pauses until the caller calls Next again.
+func (it *PageIterator) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	it.concurrency = n
+}
+
+// SetLimit sets the last page the iterator fetches, so that parallel fetches
+// never run past it. A limit of 0 or less removes the limit.
+func (it *PageIterator) SetLimit(last int) {
+	if last < 0 {
+		last = 0
+	}
+	it.limit = last
+}
+
+// Next fetches the next page. It returns false when the listing is exhausted
+// or the page could not be fetched.
+func (it *PageIterator) Next(ctx context.Context) bool {
+	if it.done {
+		return false
+	}
+	if it.limit > 0 && it.next > it.limit {
+		it.done = true
+		it.err = nil
+		return false
+	}
+
+	sites, ok := it.fetched[it.next]
+	if !ok {
+		var err error
+		if it.concurrency > 1 {
+			sites, err = it.fetchBatch(ctx)
+		} else {
+			sites, err = it.client.FetchPage(ctx, it.query, it.next)
+		}
+		if err != nil {
+			it.err = err
+			return false
+		}
+	}
+	delete(it.fetched, it.next)
+	it.err = nil
+
+	if len(sites) == 0 {
+		it.done = true
+		return false
+	}
+
+	it.page = Page{Number: it.next, Sites: sites}
+	it.next++
+	return true
+}
+
+// fetchBatch fetches the next pages in parallel, keeps the ones that succeeded
+// and returns the result for the next page
+func (it *PageIterator) fetchBatch(ctx context.Context) ([]Site, error) {
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	end := it.next + it.concurrency - 1
+	if it.limit > 0 && end > it.limit {
+		end = it.limit
+	}
+
+	var missing []int
+	for page := it.next; page <= end; page++ {
+		if _, ok := it.fetched[page]; !ok {
+			missing = append(missing, page)
+		}
+	}
+
+	results := make([][]Site, len(missing))
+	errs := make([]error, len(missing))
+	var wg sync.WaitGroup
+
+	for i, page := range missing {
+		wg.Add(1)
+		go func(i, page int) {
+			defer wg.Done()
+
+			results[i], errs[i] = it.client.FetchPage(batchCtx, it.query, page)
+			if isPauseError(errs[i]) {
+				cancel()
+			}
+		}(i, page)
+	}
+	wg.Wait()
+
+	for i, page := range missing {
+		if errs[i] == nil {
+			it.fetched[page] = results[i]
+		}
+	}
+
+	if sites, ok := it.fetched[it.next]; ok {
+		return sites, nil
+	}
+
+	// The next page may only have been cancelled because another worker
+	// was asked for verification, so report that instead
+	for _, err := range errs {
+		if isPauseError(err) {
+			return nil, err
+		}
+	}
+	return nil, errs[0]
+}
+
+// isPauseError reports whether err requires every worker to stop until the caller intervenes
+func isPauseError(err error) bool {
+	return errors.Is(err, ErrVerificationRequired) || errors.Is(err, ErrRateLimited)
+}
+
+// Page returns the page fetched by the last successful call to Next
+func (it *PageIterator) Page() Page {
+	return it.page
+}
+
+// NextPage returns the number of the page the next call to Next will fetch
+func (it *PageIterator) NextPage() int {
+	return it.next
+}
+
+// Err returns the error that stopped the last call to Next, if any
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// SiteIterator walks the sites of a query one at a time, in page order.
+// It follows the same error and retry rules as PageIterator.
+type SiteIterator struct {
+	pages   *PageIterator
+	pending []Site
+	site    Site
+	page    int
+}
+
+// Sites returns an iterator over the sites of q, starting at page start
+func (c *Client) Sites(q *Query, start int) *SiteIterator {
+	return &SiteIterator{pages: c.Pages(q, start)}
+}
+
+// Next advances to the next site, fetching a new page when needed
+func (it *SiteIterator) Next(ctx context.Context) bool {
+	for len(it.pending) == 0 {
+		if !it.pages.Next(ctx) {
+			return false
+		}
+		page := it.pages.Page()
+		it.pending, it.page = page.Sites, page.Number
+	}
+
+	it.site, it.pending = it.pending[0], it.pending[1:]
+	return true
+}
+
+// Site returns the current site
+func (it *SiteIterator) Site() Site {
+	return it.site
+}
+
+// Page returns the number of the page the current site was found on
+func (it *SiteIterator) Page() int {
+	return it.page
+}
+
+// Err returns the error that stopped the last call to Next, if any
+func (it *SiteIterator) Err() error {
+	return it.pages.Err()
+}
@@ -0,0 +1,404 @@
+// Package myipmstest provides a fake myip.ms server for offline testing.
+//
+// The server speaks just enough of the site's protocol for a myipms.Client:
+// paginated ajax_table sites listings, the Human Verification form with a
+// captcha image, and the "exceeded page visit limit" page.
+package myipmstest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+const (
+	// DefaultCaptchaAnswer is the answer accepted by the fake captcha unless Options says otherwise
+	DefaultCaptchaAnswer = "4271"
+
+	// VerifiedCookie is set by the server when a captcha is solved
+	VerifiedCookie = "s2_verified"
+)
+
+// Options configures a fake server
+type Options struct {
+	// Sites is the number of sites in the listing (default 120)
+	Sites int
+	// PageSize is the number of sites per page (default 50)
+	PageSize int
+	// CaptchaAnswer is the text accepted by the captcha (default DefaultCaptchaAnswer)
+	CaptchaAnswer string
+	// VerifyAfter requires human verification after this many successful page
+	// requests; the counter restarts after every solved captcha. Zero disables it.
+	VerifyAfter int
+}
+
+// Server is a fake myip.ms site backed by an httptest.Server
+type Server struct {
+	*httptest.Server
+
+	opts  Options
+	sites []myipms.Site
+
+	mu          sync.Mutex
+	served      int
+	verifying   bool
+	rateLimited bool
+	token       string
+	paths       []string
+}
+
+// NewServer starts a fake myip.ms server. Callers must Close it when done.
+func NewServer(opts Options) *Server {
+	if opts.Sites == 0 {
+		opts.Sites = 120
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = 50
+	}
+	if opts.CaptchaAnswer == "" {
+		opts.CaptchaAnswer = DefaultCaptchaAnswer
+	}
+
+	s := &Server{opts: opts, sites: generateSites(opts.Sites)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ajax_table/sites/", s.handleSites)
+	mux.HandleFunc("/ajax_table/web_hosting/", s.handleCatalog("/view/web_hosting/", FakeOwners))
+	mux.HandleFunc("/ajax_table/hosts/", s.handleCatalog("/view/hosts/", FakeHosts))
+	mux.HandleFunc("/ajax_table/dns/", s.handleCatalog("/view/dns/", FakeDNS))
+	mux.HandleFunc("/browse/sites/", s.handleBrowse)
+	mux.HandleFunc("/captcha.php", s.handleCaptchaImage)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Sites returns every site of the listing, in page order
+func (s *Server) Sites() []myipms.Site {
+	return append([]myipms.Site(nil), s.sites...)
+}
+
+// Pages returns the number of non-empty pages in the listing
+func (s *Server) Pages() int {
+	return (len(s.sites) + s.opts.PageSize - 1) / s.opts.PageSize
+}
+
+// RequireVerification makes every listing request answer with the Human
+// Verification form until a captcha is solved
+func (s *Server) RequireVerification() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verifying = true
+}
+
+// SetRateLimited makes every listing request answer with the page visit limit error
+func (s *Server) SetRateLimited(limited bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited = limited
+}
+
+// Paths returns the paths of every listing request received so far
+func (s *Server) Paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.paths...)
+}
+
+// handleSites serves /ajax_table/sites/{page}/{filters...}
+func (s *Server) handleSites(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.ParseForm()
+
+	rest := strings.TrimPrefix(r.URL.Path, "/ajax_table/sites/")
+	page, err := strconv.Atoi(strings.SplitN(rest, "/", 2)[0])
+	if err != nil || page < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !s.admit(w, r) {
+		return
+	}
+	writeHTML(w, s.sitesPage(page))
+}
+
+// handleCatalog serves /ajax_table/{kind}/{page}, a paginated listing of
+// the given catalog entries
+func (s *Server) handleCatalog(prefix string, entries []CatalogEntry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		r.ParseForm()
+
+		page, err := strconv.Atoi(path.Base(r.URL.Path))
+		if err != nil || page < 1 {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !s.admit(w, r) {
+			return
+		}
+		writeHTML(w, catalogPage(prefix, entries, page, s.opts.PageSize))
+	}
+}
+
+// admit records a listing request and answers it with the verification form
+// or the rate limit error when those are due. It reports whether the caller
+// should serve the listing.
+func (s *Server) admit(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	s.paths = append(s.paths, r.URL.Path)
+	if s.opts.VerifyAfter > 0 && s.served >= s.opts.VerifyAfter {
+		s.verifying = true
+	}
+
+	switch {
+	case s.rateLimited:
+		s.mu.Unlock()
+		writeHTML(w, rateLimitPage)
+		return false
+	case s.verifying:
+		token := s.newTokenLocked()
+		s.mu.Unlock()
+		writeHTML(w, verificationPage(token))
+		return false
+	}
+
+	s.served++
+	s.mu.Unlock()
+	return true
+}
+
+// handleBrowse accepts captcha answers posted to /browse/sites/1
+func (s *Server) handleBrowse(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := r.PostFormValue("captcha_token")
+	answer := r.PostFormValue("p_captcha_response")
+
+	if s.verifying && (token == "" || token != s.token || answer != s.opts.CaptchaAnswer) {
+		writeHTML(w, verificationPage(s.newTokenLocked()))
+		return
+	}
+
+	s.verifying = false
+	s.served = 0
+	s.token = ""
+	http.SetCookie(w, &http.Cookie{Name: VerifiedCookie, Value: token, Path: "/"})
+	writeHTML(w, browsePage())
+}
+
+// handleCaptchaImage serves a PNG showing the captcha answer
+func (s *Server) handleCaptchaImage(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, captchaImage(s.opts.CaptchaAnswer)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+// newTokenLocked issues a new captcha token. s.mu must be held.
+func (s *Server) newTokenLocked() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	s.token = hex.EncodeToString(b)
+	return s.token
+}
+
+// sitesPage renders one page of the listing; pages past the end have no rows
+func (s *Server) sitesPage(page int) string {
+	var b strings.Builder
+	b.WriteString("<table id='sites_tbl' class='ajax_table'>\n<thead><tr><th>#</th><th>Web Site</th><th>Website IP Address</th><th>Web Hosting Company / IP Owner</th><th>Web Hosting Location</th><th>Popularity Rank</th><th>Visitors per Day</th></tr></thead>\n<tbody>\n")
+
+	start := (page - 1) * s.opts.PageSize
+	for i := start; i < start+s.opts.PageSize && i < len(s.sites); i++ {
+		site := s.sites[i]
+		fmt.Fprintf(&b, "<tr><td class='row_no'>%d</td><td class='row_name'><a href='/view/sites/%d/%s'>%s</a></td><td><a href='/view/ip/%s'>%s</a></td><td><a href='/view/web_hosting/1'>%s</a></td><td>%s</td><td>%d</td><td>%s</td></tr>\n",
+			i+1, i+1, site.Domain, site.Domain, site.IP, site.IP,
+			html.EscapeString(site.Owner), html.EscapeString(site.Country), site.Rank, formatCount(site.Visitors))
+	}
+
+	b.WriteString("</tbody>\n</table>\n")
+	return b.String()
+}
+
+// catalogPage renders one page of a catalog listing, linking each entry to
+// prefix+ID as the site does; pages past the end have no rows
+func catalogPage(prefix string, entries []CatalogEntry, page, pageSize int) string {
+	var b strings.Builder
+	b.WriteString("<table class='ajax_table'>\n<thead><tr><th>#</th><th>Name</th><th>Sites</th></tr></thead>\n<tbody>\n")
+
+	start := (page - 1) * pageSize
+	for i := start; i < start+pageSize && i < len(entries); i++ {
+		e := entries[i]
+		fmt.Fprintf(&b, "<tr><td class='row_no'>%d</td><td><a href='%s%s/%s' title='%s'>%s</a></td><td>%s</td></tr>\n",
+			i+1, prefix, e.ID, strings.ReplaceAll(strings.ToLower(e.Name), " ", "_"),
+			html.EscapeString(e.Name), html.EscapeString(e.Name), formatCount((len(entries)-i)*100))
+	}
+
+	b.WriteString("</tbody>\n</table>\n")
+	return b.String()
+}
+
+// browsePage renders the browse page with its country selector
+func browsePage() string {
+	var b strings.Builder
+	b.WriteString("<html><body><h1>Browse Sites</h1>\n<select name='countryID'>\n<option value=''>All Countries\n")
+	for _, e := range FakeCountries {
+		fmt.Fprintf(&b, "<option value='%s'>%s\n", e.ID, html.EscapeString(e.Name))
+	}
+	b.WriteString("</select>\n</body></html>")
+	return b.String()
+}
+
+// verificationPage renders the Human Verification form
+func verificationPage(token string) string {
+	return fmt.Sprintf(`<div class='captcha_box'>
+<h1>Human Verification</h1>
+<form method='post' action='/browse/sites/1'>
+<input type='hidden' name='captcha_token' value='%s'>
+<img src='/captcha.php?sid=%s' alt='captcha'>
+<input type='text' name='p_captcha_response' value=''>
+</form>
+</div>
+`, token, token)
+}
+
+const rateLimitPage = `<div class='error_box'><p>You have exceeded page visit limit. Please try again later.</p></div>`
+
+// writeHTML writes an HTML response
+func writeHTML(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(body))
+}
+
+// CatalogEntry is one filter option listed by the fake server
+type CatalogEntry struct {
+	Name, ID string
+}
+
+// Filter options listed by the fake server's owner, host, DNS and country listings
+var (
+	FakeOwners = []CatalogEntry{
+		{"Cloudflare, Inc", "376714"}, {"Amazon.com, Inc", "7"}, {"Google Inc", "2"},
+		{"Hetzner Online Gmbh", "14"}, {"Example Hosting Company, LLC", "900001"},
+	}
+	FakeHosts = []CatalogEntry{
+		{"cloudflare.com", "9"}, {"amazonaws.com", "3"}, {"example-host.net", "900002"},
+	}
+	FakeDNS = []CatalogEntry{
+		{"ns.cloudflare.com", "11"}, {"awsdns", "5"}, {"ns1.example-dns.org", "900003"},
+	}
+	FakeCountries = []CatalogEntry{
+		{"United States", "USA"}, {"Germany", "DEU"}, {"Japan", "JPN"}, {"Côte d'Ivoire", "CIV"},
+	}
+)
+
+var (
+	fakeOwners    = []string{"Cloudflare, Inc", "Amazon.com, Inc", "Google Inc", "Hetzner Online Gmbh"}
+	fakeCountries = []string{"USA", "Germany", "Japan", "United Kingdom"}
+)
+
+// generateSites builds a deterministic listing of n sites
+func generateSites(n int) []myipms.Site {
+	sites := make([]myipms.Site, n)
+	for i := range sites {
+		sites[i] = myipms.Site{
+			Domain:   fmt.Sprintf("site%04d.example", i+1),
+			IP:       fmt.Sprintf("10.%d.%d.%d", i/65536%256, i/256%256, i%256),
+			Owner:    fakeOwners[i%len(fakeOwners)],
+			Country:  fakeCountries[i%len(fakeCountries)],
+			Rank:     i + 1,
+			Visitors: (n - i) * 1000,
+		}
+	}
+	return sites
+}
+
+// formatCount formats n with thousands separators, as the site does
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// digitGlyphs is a 3x5 bitmap font for the captcha digits
+var digitGlyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+}
+
+// captchaImage draws the answer in large pixels, leaving non-digits blank
+func captchaImage(answer string) image.Image {
+	const scale, pad = 6, 2
+
+	width := (len(answer)*4 - 1 + 2*pad) * scale
+	height := (5 + 2*pad) * scale
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	bg := color.RGBA{R: 0xf4, G: 0xf1, B: 0xe8, A: 0xff}
+	fg := color.RGBA{R: 0x20, G: 0x30, B: 0x80, A: 0xff}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, bg)
+		}
+	}
+
+	for i, r := range answer {
+		glyph, ok := digitGlyphs[r]
+		if !ok {
+			continue
+		}
+		for gy, line := range glyph {
+			for gx, c := range line {
+				if c != '#' {
+					continue
+				}
+				x0 := (pad + i*4 + gx) * scale
+				y0 := (pad + gy) * scale
+				for y := y0; y < y0+scale; y++ {
+					for x := x0; x < x0+scale; x++ {
+						img.Set(x, y, fg)
+					}
+				}
+			}
+		}
+	}
+
+	return img
+}
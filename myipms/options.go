@@ -1,7 +1,7 @@
-package main
+package myipms
 
-// Owners mapping - maps owner name to their ID
-var owners = map[string]int{
+// Owners maps hosting company (IP owner) names to their myip.ms ID
+var Owners = map[string]int{
 	"10Dence Hispahard, S.L":            323834,
 	"115114 Moscow":                     1080011,
 	"16 Collyer Quay":                   1146671,
@@ -505,8 +505,8 @@ var owners = map[string]int{
 	"Zoner Oy":                          267438,
 }
 
-// Countries mapping - maps country name to country code
-var countries = map[string]string{
+// Countries maps country names to their myip.ms country code
+var Countries = map[string]string{
 	"Afghanistan":                       "AFG",
 	"Aland Islands":                     "ALA",
 	"Albania":                           "ALB",
@@ -762,8 +762,8 @@ var countries = map[string]string{
 	"Zimbabwe":                             "ZWE",
 }
 
-// Hosts mapping - maps host name to their ID
-var hosts = map[string]int{
+// Hosts maps host names to their myip.ms ID
+var Hosts = map[string]int{
 	"163data.com.cn":            388,
 	"16clouds.com":              1407,
 	"1blu.de":                   3659,
@@ -1267,8 +1267,8 @@ var hosts = map[string]int{
 	"zxcs.nl":                   496725,
 }
 
-// DNS records mapping - maps DNS name to their ID
-var dns = map[string]int{
+// DNS maps DNS server names to their myip.ms ID
+var DNS = map[string]int{
 	"01.dnsv.jp":                 446,
 	"02.dnsv.jp":                 447,
 	"03.dnsv.jp":                 643043,
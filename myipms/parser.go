@@ -0,0 +1,357 @@
+package myipms
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Site holds a single row of the myip.ms sites table
+type Site struct {
+	Domain   string `json:"domain"`
+	IP       string `json:"ip"`
+	Owner    string `json:"owner"`
+	Country  string `json:"country"`
+	Rank     int    `json:"rank"`
+	Visitors int    `json:"visitors"`
+}
+
+// Column order of the sites table, relative to the row_name cell
+const (
+	colIP = iota
+	colOwner
+	colCountry
+	colRank
+	colVisitors
+)
+
+// tableCell holds the class list and collapsed text of a single <td>
+type tableCell struct {
+	classes []string
+	text    []string
+}
+
+// extractSites parses every row of the sites table into a Site.
+// Rows of tables nested inside a cell are ignored.
+func extractSites(content string) []Site {
+	z := html.NewTokenizer(strings.NewReader(content))
+
+	var sites []Site
+	var row []*tableCell
+	var cell *tableCell
+	inRow := false
+	depth, rowDepth := 0, 0
+
+	endRow := func() {
+		if inRow {
+			if site, ok := siteFromRow(row); ok {
+				sites = append(sites, site)
+			}
+		}
+		row, cell, inRow = nil, nil, false
+	}
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			endRow()
+			return sites
+
+		case html.StartTagToken:
+			tok := z.Token()
+			switch tok.DataAtom {
+			case atom.Table:
+				depth++
+			case atom.Tr:
+				if inRow && depth > rowDepth {
+					continue
+				}
+				endRow()
+				inRow, rowDepth = true, depth
+			case atom.Td, atom.Th:
+				if inRow && depth == rowDepth {
+					cell = &tableCell{classes: strings.Fields(attr(tok, "class"))}
+					row = append(row, cell)
+				}
+			}
+
+		case html.EndTagToken:
+			tok := z.Token()
+			switch tok.DataAtom {
+			case atom.Table:
+				if inRow && depth == rowDepth {
+					endRow()
+				}
+				if depth > 0 {
+					depth--
+				}
+			case atom.Tr:
+				if depth == rowDepth {
+					endRow()
+				}
+			case atom.Td, atom.Th:
+				if depth == rowDepth {
+					cell = nil
+				}
+			}
+
+		case html.TextToken:
+			if cell != nil && depth == rowDepth {
+				cell.text = append(cell.text, strings.Fields(string(z.Text()))...)
+			}
+		}
+	}
+}
+
+// siteFromRow builds a Site from the cells of a row, if the row has a row_name cell
+func siteFromRow(row []*tableCell) (Site, bool) {
+	nameIdx := -1
+	for i, c := range row {
+		if hasClass(c.classes, "row_name") {
+			nameIdx = i
+			break
+		}
+	}
+	if nameIdx < 0 {
+		return Site{}, false
+	}
+
+	site := Site{Domain: strings.Join(row[nameIdx].text, " ")}
+	if site.Domain == "" {
+		return Site{}, false
+	}
+
+	rest := row[nameIdx+1:]
+	column := func(i int) string {
+		if i < len(rest) {
+			return strings.Join(rest[i].text, " ")
+		}
+		return ""
+	}
+
+	site.IP = column(colIP)
+	site.Owner = column(colOwner)
+	site.Country = column(colCountry)
+	site.Rank = parseCount(column(colRank))
+	site.Visitors = parseCount(column(colVisitors))
+
+	return site, true
+}
+
+// parseCount parses numbers such as "1,234" or "#12", returning 0 if none is found
+func parseCount(s string) int {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		if r == ',' || r == '.' || r == ' ' || r == '#' {
+			return -1
+		}
+		return r
+	}, s)
+
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// extractCaptchaToken extracts the captcha token from HTML
+func extractCaptchaToken(content string) string {
+	tok, ok := findTag(content, atom.Input, func(t html.Token) bool {
+		return attr(t, "name") == "captcha_token"
+	})
+	if !ok {
+		return ""
+	}
+	return attr(tok, "value")
+}
+
+// extractCaptchaURL extracts the captcha image URL from HTML, resolving
+// relative paths against baseURL
+func extractCaptchaURL(content, baseURL string) string {
+	tok, ok := findTag(content, atom.Img, func(t html.Token) bool {
+		return strings.Contains(attr(t, "src"), "captcha.php")
+	})
+	if !ok {
+		return ""
+	}
+
+	captchaPath := attr(tok, "src")
+	base, err := url.Parse(baseURL + "/")
+	if err != nil {
+		return captchaPath
+	}
+	ref, err := url.Parse(captchaPath)
+	if err != nil {
+		return captchaPath
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// findTag returns the first start or self-closing tag of the given kind that matches
+func findTag(content string, a atom.Atom, match func(html.Token) bool) (html.Token, bool) {
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return html.Token{}, false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if tok.DataAtom == a && match(tok) {
+				return tok, true
+			}
+		}
+	}
+}
+
+// hasTable reports whether the HTML contains a table, even one without rows
+func hasTable(content string) bool {
+	_, ok := findTag(content, atom.Table, func(html.Token) bool { return true })
+	return ok
+}
+
+// attr returns the value of the named attribute, or "" if it is missing
+func attr(tok html.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Key == name {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// hasClass reports whether the class list contains the given class
+func hasClass(classes []string, class string) bool {
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// isCookieExpired checks if the response indicates expired cookies
+func isCookieExpired(html string) bool {
+	return strings.Contains(html, "Human Verification")
+}
+
+// isIPLimitExceeded checks if the response indicates IP limit exceeded
+func isIPLimitExceeded(html string) bool {
+	return strings.Contains(html, "You have exceeded page visit limit") || strings.Contains(html, "Error loading data")
+}
+
+// catalogEntry is a named filter option and its ID
+type catalogEntry struct {
+	name, id string
+}
+
+// extractLinkEntries collects the links whose path starts with prefix, such as
+// /view/web_hosting/615/Amazon.com_Inc, as entries named by the link's title or
+// text and identified by the path segment after the prefix
+func extractLinkEntries(content, prefix string) []catalogEntry {
+	z := html.NewTokenizer(strings.NewReader(content))
+
+	var entries []catalogEntry
+	var current *catalogEntry
+	var text []string
+	seen := make(map[string]bool)
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return entries
+
+		case html.StartTagToken:
+			tok := z.Token()
+			if tok.DataAtom != atom.A {
+				continue
+			}
+			href := attr(tok, "href")
+			if !strings.HasPrefix(href, prefix) {
+				continue
+			}
+			id := strings.SplitN(strings.TrimPrefix(href, prefix), "/", 2)[0]
+			if id == "" {
+				continue
+			}
+			current, text = &catalogEntry{name: attr(tok, "title"), id: id}, nil
+
+		case html.EndTagToken:
+			if current == nil || z.Token().DataAtom != atom.A {
+				continue
+			}
+			if current.name == "" {
+				current.name = strings.Join(text, " ")
+			}
+			if current.name != "" && !seen[current.name] {
+				seen[current.name] = true
+				entries = append(entries, *current)
+			}
+			current = nil
+
+		case html.TextToken:
+			if current != nil {
+				text = append(text, strings.Fields(string(z.Text()))...)
+			}
+		}
+	}
+}
+
+// extractSelectEntries collects the options of the named <select>, skipping
+// options without a value
+func extractSelectEntries(content, name string) []catalogEntry {
+	z := html.NewTokenizer(strings.NewReader(content))
+
+	var entries []catalogEntry
+	var current *catalogEntry
+	var text []string
+	inSelect := false
+
+	// </option> is optional, so an option also ends at the next one or at </select>
+	endOption := func() {
+		if current != nil && current.id != "" {
+			current.name = strings.Join(text, " ")
+			if current.name != "" {
+				entries = append(entries, *current)
+			}
+		}
+		current = nil
+	}
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			endOption()
+			return entries
+
+		case html.StartTagToken:
+			tok := z.Token()
+			switch {
+			case tok.DataAtom == atom.Select:
+				inSelect = attr(tok, "name") == name
+			case tok.DataAtom == atom.Option && inSelect:
+				endOption()
+				current, text = &catalogEntry{id: attr(tok, "value")}, nil
+			}
+
+		case html.EndTagToken:
+			switch z.Token().DataAtom {
+			case atom.Select:
+				endOption()
+				inSelect = false
+			case atom.Option:
+				endOption()
+			}
+
+		case html.TextToken:
+			if current != nil {
+				text = append(text, strings.Fields(string(z.Text()))...)
+			}
+		}
+	}
+}
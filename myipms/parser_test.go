@@ -0,0 +1,145 @@
+package myipms
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// readFixture returns the contents of a saved response in testdata
+func readFixture(t testing.TB, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestExtractSites(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []Site
+	}{
+		{
+			fixture: "sites_page.html",
+			want: []Site{
+				{Domain: "google.com", IP: "142.250.185.78", Owner: "Google Inc", Country: "USA", Rank: 1, Visitors: 42000000},
+				{Domain: "youtube.com", IP: "142.250.185.110", Owner: "Google Inc", Country: "USA", Rank: 2, Visitors: 31500000},
+				{Domain: "example.co.uk", IP: "93.184.216.34", Owner: "Amazon.com, Inc", Country: "United Kingdom", Rank: 1204, Visitors: 58300},
+			},
+		},
+		{
+			fixture: "sites_page_variant.html",
+			want: []Site{
+				{Domain: "wikipedia.org", IP: "208.80.154.224", Owner: "Wikimedia Foundation & Co", Country: "USA", Rank: 51, Visitors: 9876543},
+				{Domain: "bücher.example", IP: "2001:db8::1", Country: "Germany"},
+			},
+		},
+		{fixture: "sites_empty.html"},
+		{fixture: "captcha_form.html"},
+		{fixture: "rate_limited.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got := extractSites(readFixture(t, tt.fixture))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractSites() =\n  %+v\nwant\n  %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractCaptcha(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		wantToken string
+		wantURL   string
+	}{
+		{"captcha_form.html", "a1b2c3d4e5f6", DefaultBaseURL + "/captcha.php?sid=8f14e45fceea167a"},
+		{"captcha_form_variant.html", "tok-9f8e7d", "https://myip.ms/captcha.php?sid=c9f0f895fb98ab91&r=2"},
+		{"sites_page.html", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			content := readFixture(t, tt.fixture)
+			if got := extractCaptchaToken(content); got != tt.wantToken {
+				t.Errorf("extractCaptchaToken() = %q, want %q", got, tt.wantToken)
+			}
+			if got := extractCaptchaURL(content, DefaultBaseURL); got != tt.wantURL {
+				t.Errorf("extractCaptchaURL() = %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestResponseMarkers(t *testing.T) {
+	if !isCookieExpired(readFixture(t, "captcha_form.html")) {
+		t.Error("captcha_form.html not detected as human verification")
+	}
+	if !isIPLimitExceeded(readFixture(t, "rate_limited.html")) {
+		t.Error("rate_limited.html not detected as page visit limit")
+	}
+	if content := readFixture(t, "sites_page.html"); isCookieExpired(content) || isIPLimitExceeded(content) {
+		t.Error("sites_page.html detected as an error response")
+	}
+	if !hasTable(readFixture(t, "sites_empty.html")) {
+		t.Error("sites_empty.html not detected as a sites table")
+	}
+	if hasTable(readFixture(t, "rate_limited.html")) {
+		t.Error("rate_limited.html detected as a sites table")
+	}
+}
+
+// addFixtureSeeds seeds a fuzz corpus with every saved response
+func addFixtureSeeds(f *testing.F) {
+	matches, err := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, m := range matches {
+		f.Add(readFixture(f, filepath.Base(m)))
+	}
+}
+
+func FuzzExtractSites(f *testing.F) {
+	addFixtureSeeds(f)
+	f.Add("<tr><td class=row_name>a.com<td>1.1.1.1")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		for _, site := range extractSites(content) {
+			if site.Domain == "" {
+				t.Fatalf("site with empty domain: %+v", site)
+			}
+			if site.Domain != strings.TrimSpace(site.Domain) || strings.Contains(site.Domain, "  ") {
+				t.Fatalf("domain not collapsed: %q", site.Domain)
+			}
+			if site.Rank < 0 || site.Visitors < 0 {
+				t.Fatalf("negative count: %+v", site)
+			}
+			if utf8.ValidString(content) && !utf8.ValidString(site.Domain) {
+				t.Fatalf("invalid UTF-8 domain from valid input: %q", site.Domain)
+			}
+		}
+	})
+}
+
+func FuzzExtractCaptcha(f *testing.F) {
+	addFixtureSeeds(f)
+	f.Add("<img src=captcha.php><input name=captcha_token value=x>")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		token := extractCaptchaToken(content)
+		if token != strings.TrimSpace(token) {
+			t.Fatalf("token not trimmed: %q", token)
+		}
+		if u := extractCaptchaURL(content, DefaultBaseURL); u != "" && !strings.Contains(u, "captcha.php") {
+			t.Fatalf("captcha URL without captcha.php: %q", u)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package myipms
+
+import "fmt"
+
+// Filter holds resolved filter information
+type Filter struct {
+	OwnerName    string
+	OwnerID      int
+	CountryCode  string
+	CountryName  string
+	HostName     string
+	HostID       int
+	DNSName      string
+	DNSID        int
+	URLFilter    string
+	RankFrom     int
+	RankTo       int
+	IPFrom       string
+	IPTo         string
+	VisitorsFrom int
+	VisitorsTo   int
+}
+
+// Query is a Filter compiled into the URL path template used to request its pages
+type Query struct {
+	filter      Filter
+	urlTemplate string
+}
+
+// NewQuery builds a query from a filter
+func NewQuery(f *Filter) *Query {
+	return &Query{
+		filter:      *f,
+		urlTemplate: buildURLTemplate(f),
+	}
+}
+
+// Filter returns the filter the query was built from
+func (q *Query) Filter() Filter {
+	return q.filter
+}
+
+// PagePath returns the path of the given page of the query, relative to the base URL
+func (q *Query) PagePath(page int) string {
+	return fmt.Sprintf(q.urlTemplate, page)
+}
+
+// buildURLTemplate constructs a URL path template with all filters, leaving page as placeholder
+func buildURLTemplate(f *Filter) string {
+	url := "/ajax_table/sites/%d"
+
+	if f.URLFilter != "" {
+		url += fmt.Sprintf("/url/%s", f.URLFilter)
+	}
+
+	if f.CountryCode != "" {
+		url += fmt.Sprintf("/countryID/%s", f.CountryCode)
+	}
+
+	if f.RankFrom > 0 && f.RankTo > 0 {
+		url += fmt.Sprintf("/rank/%d/rankii/%d", f.RankFrom, f.RankTo)
+	}
+
+	if f.IPFrom != "" && f.IPTo != "" {
+		url += fmt.Sprintf("/ipID/%s/ipIDii/%s", formatIP(f.IPFrom), formatIP(f.IPTo))
+	}
+
+	if f.OwnerID != 0 {
+		url += fmt.Sprintf("/own/%d", f.OwnerID)
+	}
+
+	if f.HostID != 0 {
+		url += fmt.Sprintf("/hostID/%d", f.HostID)
+	}
+
+	if f.DNSID != 0 {
+		url += fmt.Sprintf("/dns/%d", f.DNSID)
+	}
+
+	if f.VisitorsFrom > 0 && f.VisitorsTo > 0 {
+		url += fmt.Sprintf("/cntVisitors/%d/cntVisitorsii/%d", f.VisitorsFrom, f.VisitorsTo)
+	}
+
+	return url
+}
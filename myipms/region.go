@@ -0,0 +1,147 @@
+package myipms
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Region is a named group of countries, such as a continent
+type Region struct {
+	Name    string
+	Aliases []string
+	// Codes are the ISO 3166-1 alpha-3 codes of the member countries
+	Codes []string
+}
+
+// Member codes of the regions. The continents are built from the subregions
+// of the UN geoscheme.
+var (
+	northernAfrica = []string{"DZA", "EGY", "LBY", "MAR", "SDN", "TUN", "ESH"}
+	easternAfrica  = []string{"BDI", "COM", "DJI", "ERI", "ETH", "KEN", "MDG", "MWI", "MUS", "MYT", "MOZ", "REU", "RWA", "SYC", "SOM", "SSD", "TZA", "UGA", "ZMB", "ZWE", "IOT", "ATF"}
+	middleAfrica   = []string{"AGO", "CMR", "CAF", "TCD", "COG", "COD", "GNQ", "GAB", "STP"}
+	southernAfrica = []string{"BWA", "SWZ", "LSO", "NAM", "ZAF"}
+	westernAfrica  = []string{"BEN", "BFA", "CPV", "CIV", "GMB", "GHA", "GIN", "GNB", "LBR", "MLI", "MRT", "NER", "NGA", "SHN", "SEN", "SLE", "TGO"}
+
+	caribbean       = []string{"AIA", "ATG", "ABW", "BHS", "BRB", "BES", "VGB", "CYM", "CUB", "CUW", "DMA", "DOM", "GRD", "GLP", "HTI", "JAM", "MTQ", "MSR", "PRI", "BLM", "KNA", "LCA", "MAF", "VCT", "SXM", "TTO", "TCA", "VIR", "ANT"}
+	centralAmerica  = []string{"BLZ", "CRI", "SLV", "GTM", "HND", "MEX", "NIC", "PAN"}
+	southAmerica    = []string{"ARG", "BOL", "BVT", "BRA", "CHL", "COL", "ECU", "FLK", "GUF", "GUY", "PRY", "PER", "SGS", "SUR", "URY", "VEN"}
+	northernAmerica = []string{"BMU", "CAN", "GRL", "SPM", "USA"}
+
+	centralAsia      = []string{"KAZ", "KGZ", "TJK", "TKM", "UZB"}
+	easternAsia      = []string{"CHN", "HKG", "MAC", "PRK", "JPN", "MNG", "KOR", "TWN"}
+	southEasternAsia = []string{"BRN", "KHM", "IDN", "LAO", "MYS", "MMR", "PHL", "SGP", "THA", "TLS", "VNM"}
+	southernAsia     = []string{"AFG", "BGD", "BTN", "IND", "IRN", "MDV", "NPL", "PAK", "LKA"}
+	westernAsia      = []string{"ARM", "AZE", "BHR", "CYP", "GEO", "IRQ", "ISR", "JOR", "KWT", "LBN", "OMN", "QAT", "SAU", "PSE", "SYR", "TUR", "ARE", "YEM"}
+	easternEurope    = []string{"BLR", "BGR", "CZE", "HUN", "POL", "MDA", "ROU", "RUS", "SVK", "UKR"}
+	northernEurope   = []string{"ALA", "DNK", "EST", "FRO", "FIN", "GGY", "ISL", "IRL", "IMN", "JEY", "LVA", "LTU", "NOR", "SJM", "SWE", "GBR"}
+	southernEurope   = []string{"ALB", "AND", "BIH", "HRV", "GIB", "GRC", "VAT", "ITA", "MLT", "MNE", "MKD", "PRT", "SMR", "SRB", "SVN", "ESP", "XKX"}
+	westernEurope    = []string{"AUT", "BEL", "FRA", "DEU", "LIE", "LUX", "MCO", "NLD", "CHE"}
+	australiaNZ      = []string{"AUS", "NZL", "NFK", "CXR", "CCK", "HMD"}
+	melanesia        = []string{"FJI", "NCL", "PNG", "SLB", "VUT"}
+	micronesia       = []string{"GUM", "KIR", "MHL", "FSM", "NRU", "MNP", "PLW", "UMI"}
+	polynesia        = []string{"ASM", "COK", "PYF", "NIU", "PCN", "WSM", "TKL", "TON", "TUV", "WLF"}
+
+	asean           = []string{"BRN", "KHM", "IDN", "LAO", "MYS", "MMR", "PHL", "SGP", "THA", "VNM"}
+	middleEast      = []string{"BHR", "CYP", "EGY", "IRN", "IRQ", "ISR", "JOR", "KWT", "LBN", "OMN", "PSE", "QAT", "SAU", "SYR", "TUR", "ARE", "YEM"}
+	gulfCooperation = []string{"BHR", "KWT", "OMN", "QAT", "SAU", "ARE"}
+	europeanUnion   = []string{"AUT", "BEL", "BGR", "HRV", "CYP", "CZE", "DNK", "EST", "FIN", "FRA", "DEU", "GRC", "HUN", "IRL", "ITA", "LVA", "LTU", "LUX", "MLT", "NLD", "POL", "PRT", "ROU", "SVK", "SVN", "ESP", "SWE"}
+)
+
+// Regions lists the built-in regions: the continents, their UN subregions and
+// common political and informal groupings
+var Regions = []Region{
+	{Name: "Africa", Codes: concat(northernAfrica, easternAfrica, middleAfrica, southernAfrica, westernAfrica)},
+	{Name: "Northern Africa", Aliases: []string{"North Africa"}, Codes: northernAfrica},
+	{Name: "Eastern Africa", Aliases: []string{"East Africa"}, Codes: easternAfrica},
+	{Name: "Middle Africa", Aliases: []string{"Central Africa"}, Codes: middleAfrica},
+	{Name: "Southern Africa", Codes: southernAfrica},
+	{Name: "Western Africa", Aliases: []string{"West Africa"}, Codes: westernAfrica},
+	{Name: "Sub-Saharan Africa", Codes: concat(easternAfrica, middleAfrica, southernAfrica, westernAfrica)},
+
+	{Name: "Americas", Codes: concat(northernAmerica, centralAmerica, caribbean, southAmerica)},
+	{Name: "North America", Codes: concat(northernAmerica, centralAmerica, caribbean)},
+	{Name: "Northern America", Codes: northernAmerica},
+	{Name: "Central America", Codes: centralAmerica},
+	{Name: "Caribbean", Codes: caribbean},
+	{Name: "South America", Codes: southAmerica},
+	{Name: "Latin America", Aliases: []string{"LATAM"}, Codes: concat(centralAmerica, caribbean, southAmerica)},
+
+	{Name: "Asia", Codes: concat(centralAsia, easternAsia, southEasternAsia, southernAsia, westernAsia)},
+	{Name: "Central Asia", Codes: centralAsia},
+	{Name: "East Asia", Aliases: []string{"Eastern Asia"}, Codes: easternAsia},
+	{Name: "South-East Asia", Aliases: []string{"Southeast Asia", "South-Eastern Asia", "SEA"}, Codes: southEasternAsia},
+	{Name: "South Asia", Aliases: []string{"Southern Asia"}, Codes: southernAsia},
+	{Name: "Western Asia", Aliases: []string{"West Asia"}, Codes: westernAsia},
+	{Name: "Middle East", Codes: middleEast},
+	{Name: "ASEAN", Codes: asean},
+	{Name: "Gulf", Aliases: []string{"GCC", "Gulf States"}, Codes: gulfCooperation},
+
+	{Name: "Europe", Codes: concat(easternEurope, northernEurope, southernEurope, westernEurope)},
+	{Name: "Eastern Europe", Codes: easternEurope},
+	{Name: "Northern Europe", Codes: northernEurope},
+	{Name: "Southern Europe", Codes: southernEurope},
+	{Name: "Western Europe", Codes: westernEurope},
+	{Name: "EU", Aliases: []string{"European Union", "EU27"}, Codes: europeanUnion},
+	{Name: "Nordics", Aliases: []string{"Nordic Countries"}, Codes: []string{"DNK", "FIN", "ISL", "NOR", "SWE"}},
+	{Name: "Baltics", Aliases: []string{"Baltic States"}, Codes: []string{"EST", "LVA", "LTU"}},
+	{Name: "Benelux", Codes: []string{"BEL", "NLD", "LUX"}},
+	{Name: "DACH", Codes: []string{"DEU", "AUT", "CHE"}},
+
+	{Name: "Oceania", Codes: concat(australiaNZ, melanesia, micronesia, polynesia)},
+	{Name: "Australia and New Zealand", Aliases: []string{"ANZ", "Australasia"}, Codes: australiaNZ},
+	{Name: "Melanesia", Codes: melanesia},
+	{Name: "Micronesia", Codes: micronesia},
+	{Name: "Polynesia", Codes: polynesia},
+}
+
+// concat joins lists of country codes
+func concat(lists ...[]string) []string {
+	var codes []string
+	for _, l := range lists {
+		codes = append(codes, l...)
+	}
+	return codes
+}
+
+// FindRegion returns the region named input, or by one of its aliases,
+// ignoring case, spaces and hyphens, or nil if there is none
+func FindRegion(input string) *Region {
+	key := regionKey(input)
+	if key == "" {
+		return nil
+	}
+	for i := range Regions {
+		r := &Regions[i]
+		if regionKey(r.Name) == key {
+			return r
+		}
+		for _, alias := range r.Aliases {
+			if regionKey(alias) == key {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// regionKey normalises a region name for comparison
+func regionKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// Countries returns the names in Countries of the region's members, in the
+// region's order. Members the option tables do not have are left out.
+func (r *Region) Countries() []string {
+	var names []string
+	for _, code := range r.Codes {
+		if name := countryForCode(code); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,63 @@
+package myipms
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegionsResolve(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, r := range Regions {
+		if seen[regionKey(r.Name)] {
+			t.Errorf("region %s is listed twice", r.Name)
+		}
+		seen[regionKey(r.Name)] = true
+
+		for _, code := range r.Codes {
+			if countryForCode(code) == "" {
+				t.Errorf("region %s: no country has code %s", r.Name, code)
+			}
+		}
+	}
+}
+
+func TestFindRegion(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "EU", want: "EU"},
+		{input: "european union", want: "EU"},
+		{input: "Southeast Asia", want: "South-East Asia"},
+		{input: "south east asia", want: "South-East Asia"},
+		{input: "nordics", want: "Nordics"},
+		{input: "Atlantis"},
+		{input: " "},
+	}
+
+	for _, tt := range tests {
+		got := FindRegion(tt.input)
+		switch {
+		case got == nil && tt.want != "":
+			t.Errorf("FindRegion(%q) = nil, want %s", tt.input, tt.want)
+		case got != nil && got.Name != tt.want:
+			t.Errorf("FindRegion(%q) = %s, want %q", tt.input, got.Name, tt.want)
+		}
+	}
+}
+
+func TestRegionCountries(t *testing.T) {
+	want := []string{"Belgium", "Netherlands", "Luxembourg"}
+	if got := FindRegion("Benelux").Countries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Benelux countries = %q, want %q", got, want)
+	}
+
+	// ROU is listed by the site as ROM
+	found := false
+	for _, name := range FindRegion("EU").Countries() {
+		found = found || name == "Romania"
+	}
+	if !found {
+		t.Error("EU countries do not include Romania")
+	}
+}
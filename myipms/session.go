@@ -0,0 +1,116 @@
+package myipms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// session is the on-disk form of a client's cookie jar
+type session struct {
+	BaseURL string          `json:"base_url"`
+	SavedAt time.Time       `json:"saved_at"`
+	Cookies []sessionCookie `json:"cookies"`
+}
+
+// sessionCookie is a single cookie of a session file
+type sessionCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// SaveSession writes the current cookies to the client's session file.
+// It does nothing if the client has no session file.
+func (c *Client) SaveSession() error {
+	if c.sessionFile == "" {
+		return nil
+	}
+
+	s := session{BaseURL: c.BaseURL(), SavedAt: time.Now()}
+	for _, cookie := range c.Cookies() {
+		s.Cookies = append(s.Cookies, sessionCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.sessionFile), 0700); err != nil {
+		return fmt.Errorf("error saving session: %w", err)
+	}
+
+	tmp := c.sessionFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("error saving session: %w", err)
+	}
+	if err := os.Rename(tmp, c.sessionFile); err != nil {
+		return fmt.Errorf("error saving session: %w", err)
+	}
+	return nil
+}
+
+// loadSession fills the jar from the session file. It reports false without
+// an error when there is no session file, it was saved for another site or it
+// is corrupt; a corrupt file is kept in sessionErr for SessionError.
+func (c *Client) loadSession() (bool, error) {
+	if c.sessionFile == "" {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(c.sessionFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reading session %s: %w", c.sessionFile, err)
+	}
+
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		c.sessionErr = fmt.Errorf("invalid session %s: %w", c.sessionFile, err)
+		return false, nil
+	}
+
+	if s.BaseURL != c.BaseURL() || len(s.Cookies) == 0 {
+		return false, nil
+	}
+
+	cookies := make([]*http.Cookie, 0, len(s.Cookies))
+	for _, sc := range s.Cookies {
+		cookies = append(cookies, &http.Cookie{Name: sc.Name, Value: sc.Value})
+	}
+	c.SetCookies(cookies)
+	c.sessionSavedAt = s.SavedAt
+
+	return true, nil
+}
+
+// SessionSavedAt returns when the session the client loaded was saved, or the
+// zero time when it started from the built-in cookies
+func (c *Client) SessionSavedAt() time.Time {
+	return c.sessionSavedAt
+}
+
+// SessionError returns why the session file was ignored when it could not be
+// parsed, or nil. The client then starts from the built-in cookies, and the
+// next SaveSession overwrites the file.
+func (c *Client) SessionError() error {
+	return c.sessionErr
+}
+
+// ClearSession deletes the client's session file, so the next client starts
+// from the built-in cookies. It does nothing if there is no session file.
+func (c *Client) ClearSession() error {
+	if c.sessionFile == "" {
+		return nil
+	}
+	if err := os.Remove(c.sessionFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error clearing session: %w", err)
+	}
+	return nil
+}
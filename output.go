@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// outputFormats lists the supported -format values
+var outputFormats = []string{"txt", "jsonl", "csv", "tsv"}
+
+// isOutputFormat reports whether format is a supported -format value
+func isOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Record is a scraped site together with the page it was found on and, for
+// multi-value filters, the combination of filter values that found it
+type Record struct {
+	myipms.Site
+	Page  int    `json:"page"`
+	Query string `json:"query,omitempty"`
+}
+
+// recordColumns is the header row of the csv and tsv formats; tagged outputs
+// add a query column
+var recordColumns = []string{"domain", "ip", "owner", "country", "rank", "visitors", "page"}
+
+// fields returns the record's values in recordColumns order
+func (r Record) fields() []string {
+	return []string{
+		r.Domain,
+		r.IP,
+		r.Owner,
+		r.Country,
+		strconv.Itoa(r.Rank),
+		strconv.Itoa(r.Visitors),
+		strconv.Itoa(r.Page),
+	}
+}
+
+// setField sets the value of a csv or tsv column, ignoring unknown columns
+// and numbers that do not parse
+func (r *Record) setField(column, value string) {
+	number := func() int {
+		n, _ := strconv.Atoi(value)
+		return n
+	}
+
+	switch column {
+	case "domain":
+		r.Domain = value
+	case "ip":
+		r.IP = value
+	case "owner":
+		r.Owner = value
+	case "country":
+		r.Country = value
+	case "rank":
+		r.Rank = number()
+	case "visitors":
+		r.Visitors = number()
+	case "page":
+		r.Page = number()
+	case "query":
+		r.Query = value
+	}
+}
+
+// recordWriter writes records in one output format. Records may be buffered
+// until Flush is called.
+type recordWriter interface {
+	Write(rec Record) error
+	Flush() error
+}
+
+// newRecordWriter returns a writer for the given format. Formats with a header
+// row only write it when writeHeader is set, so appending to an existing file
+// does not repeat it. With tagged set, csv and tsv rows end with the query
+// column; jsonl carries the query whenever it is set, and txt never does.
+func newRecordWriter(format string, w io.Writer, writeHeader, tagged bool) (recordWriter, error) {
+	switch format {
+	case "txt":
+		return &textWriter{w: bufio.NewWriter(w)}, nil
+	case "jsonl":
+		buf := bufio.NewWriter(w)
+		return &jsonLinesWriter{buf: buf, enc: json.NewEncoder(buf)}, nil
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		if writeHeader {
+			header := recordColumns
+			if tagged {
+				header = append(append([]string(nil), recordColumns...), "query")
+			}
+			if err := cw.Write(header); err != nil {
+				return nil, err
+			}
+		}
+		return &delimitedWriter{w: cw, tagged: tagged}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format '%s' (expected one of %s)", format, strings.Join(outputFormats, ", "))
+	}
+}
+
+// textWriter writes one domain per line
+type textWriter struct {
+	w *bufio.Writer
+}
+
+func (tw *textWriter) Write(rec Record) error {
+	_, err := tw.w.WriteString(rec.Domain + "\n")
+	return err
+}
+
+func (tw *textWriter) Flush() error {
+	return tw.w.Flush()
+}
+
+// jsonLinesWriter writes one JSON object per line
+type jsonLinesWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (jw *jsonLinesWriter) Write(rec Record) error {
+	return jw.enc.Encode(rec)
+}
+
+func (jw *jsonLinesWriter) Flush() error {
+	return jw.buf.Flush()
+}
+
+// delimitedWriter writes csv or tsv rows in recordColumns order
+type delimitedWriter struct {
+	w      *csv.Writer
+	tagged bool
+}
+
+func (dw *delimitedWriter) Write(rec Record) error {
+	fields := rec.fields()
+	if dw.tagged {
+		fields = append(fields, rec.Query)
+	}
+	return dw.w.Write(fields)
+}
+
+func (dw *delimitedWriter) Flush() error {
+	dw.w.Flush()
+	return dw.w.Error()
+}
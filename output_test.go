@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// testRecords have owners with the csv and tsv separators and a quote in them
+var testRecords = []Record{
+	{
+		Site:  myipms.Site{Domain: "example.com", IP: "93.184.216.34", Owner: "Amazon.com, Inc", Country: "USA", Rank: 12, Visitors: 34000},
+		Page:  1,
+		Query: "country=USA; owner=Amazon.com, Inc",
+	},
+	{
+		Site:  myipms.Site{Domain: "example.de", IP: "2001:db8::1", Owner: "Hetzner \"Online\"\tGmbH", Country: "Germany", Rank: 5600},
+		Page:  2,
+		Query: "country=Germany; owner=id:45",
+	},
+	{
+		Site: myipms.Site{Domain: "Example.org", Owner: "Cloudflare, Inc"},
+		Page: 2,
+	},
+}
+
+func TestRecordsRoundTrip(t *testing.T) {
+	for _, format := range outputFormats {
+		for _, tagged := range []bool{false, true} {
+			for _, header := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%s/tagged=%t/header=%t", format, tagged, header), func(t *testing.T) {
+					var buf bytes.Buffer
+					w, err := newRecordWriter(format, &buf, header, tagged)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, rec := range testRecords {
+						if err := w.Write(rec); err != nil {
+							t.Fatal(err)
+						}
+					}
+					if err := w.Flush(); err != nil {
+						t.Fatal(err)
+					}
+
+					var got []Record
+					err = readRecords(bytes.NewReader(buf.Bytes()), format, func(rec Record) error {
+						got = append(got, rec)
+						return nil
+					})
+					if err != nil {
+						t.Fatalf("readRecords: %v", err)
+					}
+
+					if want := carriedFields(format, tagged); !reflect.DeepEqual(got, want) {
+						t.Errorf("read back\n%+v\nwant\n%+v\nfrom\n%s", got, want, buf.String())
+					}
+				})
+			}
+		}
+	}
+}
+
+// carriedFields returns testRecords reduced to the fields format keeps
+func carriedFields(format string, tagged bool) []Record {
+	want := make([]Record, len(testRecords))
+	for i, rec := range testRecords {
+		switch {
+		case format == "txt":
+			rec = Record{Site: myipms.Site{Domain: rec.Domain}}
+		case format != "jsonl" && !tagged:
+			rec.Query = ""
+		}
+		want[i] = rec
+	}
+	return want
+}
+
+func TestLoadDomainSet(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, format := range outputFormats {
+		path := filepath.Join(dir, "domains."+format)
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := newRecordWriter(format, file, true, format == "csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rec := range testRecords {
+			if err := w.Write(rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+
+		seen, err := loadDomainSet(path, format)
+		if err != nil {
+			t.Fatalf("%s: loadDomainSet: %v", format, err)
+		}
+		if len(seen) != len(testRecords) {
+			t.Errorf("%s: loaded %d domains, want %d", format, len(seen), len(testRecords))
+		}
+		for _, domain := range []string{"example.com", "EXAMPLE.DE", " example.org"} {
+			if seen.add(domain) {
+				t.Errorf("%s: %q was not loaded", format, domain)
+			}
+		}
+	}
+
+	seen, err := loadDomainSet(filepath.Join(dir, "missing.txt"), "txt")
+	if err != nil || len(seen) != 0 {
+		t.Errorf("loadDomainSet(missing file) = %d domains, %v; want an empty set", len(seen), err)
+	}
+}
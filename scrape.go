@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// scrapeResult summarises one scrape
+type scrapeResult struct {
+	Pages       int
+	Total       int
+	Duplicates  int
+	NextPage    int
+	Interrupted bool
+}
+
+// newCheckpoint returns the checkpoint a scrape of q starts from, loading it
+// when -resume is set. It updates c.StartPage to the resumed page. Resuming a
+// multi-value filter query without a checkpoint starts it afresh, since it may
+// not have been reached before.
+func newCheckpoint(q filterQuery, c *Config) (*Checkpoint, error) {
+	fresh := &Checkpoint{Filter: *q.Filter, Tag: q.Tag, NextPage: c.StartPage, Output: c.Output}
+	if !c.Resume {
+		return fresh, nil
+	}
+
+	if _, err := os.Stat(checkpointPath(c.Output, q.Tag)); q.Tag != "" && errors.Is(err, os.ErrNotExist) {
+		return fresh, nil
+	}
+
+	checkpoint, err := resumeCheckpoint(q.Filter, c, q.Tag)
+	if err != nil {
+		return nil, err
+	}
+	c.StartPage = checkpoint.NextPage
+	return checkpoint, nil
+}
+
+// runScrape scrapes the pages of filter into the configured output, solving
+// captchas as they come up. It stops early when ctx is cancelled, reporting
+// an interrupted result, and returns an error when it cannot continue.
+func runScrape(ctx context.Context, client *myipms.Client, c *Config, filter *myipms.Filter, checkpoint *Checkpoint, dashboard *Dashboard) (*scrapeResult, error) {
+	pages := client.Pages(myipms.NewQuery(filter), c.StartPage)
+	pages.SetConcurrency(c.Concurrency)
+	if c.MaxPages > 0 {
+		pages.SetLimit(c.StartPage + c.MaxPages - 1)
+	}
+
+	file, err := os.OpenFile(c.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening output file: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error opening output file: %v", err)
+	}
+	out, err := newRecordWriter(c.Format, file, info.Size() == 0, checkpoint.Tag != "")
+	if err != nil {
+		return nil, err
+	}
+
+	var dedupe *dedupeWriter
+	if c.Dedupe {
+		dedupe, err = newDedupeWriter(out, c.Output, c.Format)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Dedupe: %d domains already in %s\n", len(dedupe.seen), c.Output)
+		out = dedupe
+	}
+
+	result := &scrapeResult{Total: checkpoint.Total}
+	maxPage := c.StartPage + c.MaxPages
+	isUnlimited := c.MaxPages == 0
+
+	defer func() {
+		result.NextPage = pages.NextPage()
+		if dedupe != nil {
+			result.Duplicates = dedupe.dropped
+		}
+		if err := file.Sync(); err != nil {
+			fmt.Printf("Warning: could not sync output file: %v\n", err)
+		}
+	}()
+
+	for isUnlimited || pages.NextPage() < maxPage {
+		if ctx.Err() != nil {
+			result.Interrupted = true
+			break
+		}
+
+		dashboard.Fetching(pages.NextPage())
+		if !pages.Next(ctx) {
+			err := pages.Err()
+			if err == nil {
+				fmt.Println("No domains found")
+				if err := checkpoint.finish(); err != nil {
+					fmt.Printf("Warning: could not update checkpoint: %v\n", err)
+				}
+				break
+			}
+
+			if ctx.Err() != nil {
+				result.Interrupted = true
+				break
+			}
+
+			fmt.Printf("Error: %v\n", err)
+			dashboard.Error(errorKind(err))
+
+			var unexpected *myipms.UnexpectedResponseError
+			switch {
+			case errors.Is(err, myipms.ErrVerificationRequired):
+				// An interrupted captcha is picked up by the check at the top of the loop
+				captchaErr := solveCaptcha(ctx, client, c, dashboard)
+				if captchaErr != nil && ctx.Err() == nil {
+					return result, fmt.Errorf("failed to solve captcha: %v", captchaErr)
+				}
+				if captchaErr == nil {
+					saveSession(client)
+				}
+			case errors.Is(err, myipms.ErrRateLimited):
+				fmt.Println("IP address has been rate limited. Please:")
+				fmt.Println("1. Use a proxy or VPN to change your IP address")
+				fmt.Println("2. If using mobile internet, turn airplane mode on/off to get a new IP")
+				fmt.Printf("Then run again with %s to continue from page %d.\n", checkpoint.resumeHint(pages.NextPage()), pages.NextPage())
+				dashboard.Finish("rate_limited")
+				return result, err
+			case errors.As(err, &unexpected) && unexpected.StatusCode == http.StatusOK:
+				fmt.Println("Response content:")
+				fmt.Println(unexpected.Body)
+				fmt.Printf("Stopping. Run again with %s to retry page %d.\n", checkpoint.resumeHint(pages.NextPage()), pages.NextPage())
+				return result, err
+			}
+			continue
+		}
+
+		page := pages.Page()
+		found, skipped := len(page.Sites), 0
+		if dedupe != nil {
+			skipped = dedupe.dropped
+		}
+		for _, site := range page.Sites {
+			if err := out.Write(Record{Site: site, Page: page.Number, Query: checkpoint.Tag}); err != nil {
+				return result, fmt.Errorf("error writing output file: %v", err)
+			}
+		}
+		if err := out.Flush(); err != nil {
+			return result, fmt.Errorf("error writing output file: %v", err)
+		}
+
+		if dedupe != nil {
+			skipped = dedupe.dropped - skipped
+		}
+
+		result.Pages++
+		result.Total += found - skipped
+		if skipped > 0 {
+			fmt.Printf("Page %d: Found %d domains, %d duplicates skipped (Total: %d)\n", page.Number, found, skipped, result.Total)
+		} else {
+			fmt.Printf("Page %d: Found %d domains (Total: %d)\n", page.Number, found, result.Total)
+		}
+
+		dashboard.PageDone(page.Number, found, skipped, result.Total)
+
+		checkpoint.NextPage, checkpoint.Total = pages.NextPage(), result.Total
+		if err := checkpoint.save(); err != nil {
+			fmt.Printf("Warning: could not save checkpoint: %v\n", err)
+		}
+	}
+
+	return result, nil
+}
+
+// errorKind names the class of a page error for the dashboard's error counts
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, myipms.ErrVerificationRequired):
+		return "verification"
+	case errors.Is(err, myipms.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, myipms.ErrUnexpectedResponse):
+		return "unexpected_response"
+	default:
+		return "network"
+	}
+}
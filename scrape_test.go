@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+	"github.com/ayanrajpoot10/myipms-scraper/myipms/myipmstest"
+)
+
+// scrapeConfig returns a config scraping the fake server into a temporary file
+func scrapeConfig(t *testing.T, srv *myipmstest.Server) *Config {
+	t.Helper()
+	return &Config{
+		Output:    filepath.Join(t.TempDir(), "domains.txt"),
+		Format:    "txt",
+		BaseURL:   srv.URL,
+		StartPage: 1,
+		Captcha:   "terminal",
+	}
+}
+
+// startScrape prepares a scrape of the whole listing as runScrapeCommand does
+func startScrape(t *testing.T, c *Config) (*myipms.Client, *myipms.Filter, *Checkpoint) {
+	t.Helper()
+
+	client, err := myipms.NewClient(myipms.ClientOptions{BaseURL: c.BaseURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := &myipms.Filter{}
+	checkpoint, err := newCheckpoint(filterQuery{Filter: filter}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, filter, checkpoint
+}
+
+func TestInterruptBeforeFirstPage(t *testing.T) {
+	srv := myipmstest.NewServer(myipmstest.Options{})
+	defer srv.Close()
+	c := scrapeConfig(t, srv)
+	client, filter, checkpoint := startScrape(t, c)
+
+	// Ctrl-C before any page is done, e.g. while a captcha is pending
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := runScrape(ctx, client, c, filter, checkpoint, nil)
+	if err != nil {
+		t.Fatalf("runScrape: %v", err)
+	}
+	if !result.Interrupted || result.NextPage != 1 {
+		t.Fatalf("result = %+v, want interrupted at page 1", result)
+	}
+
+	if _, err := os.Stat(checkpointPath(c.Output, "")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("checkpoint file: %v, want none before the first page", err)
+	}
+	if got := checkpoint.resumeHint(result.NextPage); got != "-start 1" {
+		t.Errorf("resume hint = %q, want -start 1 since -resume has no checkpoint", got)
+	}
+
+	// Once a page is done, the -resume the hint offers works
+	c.MaxPages = 1
+	result, err = runScrape(context.Background(), client, c, filter, checkpoint, nil)
+	if err != nil {
+		t.Fatalf("runScrape: %v", err)
+	}
+	if got := checkpoint.resumeHint(result.NextPage); got != "-resume (or -start 2)" {
+		t.Errorf("resume hint after a page = %q, want -resume (or -start 2)", got)
+	}
+	c.Resume, c.MaxPages = true, 0
+	if _, _, resumed := startScrape(t, c); resumed.NextPage != 2 {
+		t.Errorf("-resume starts from page %d, want 2", resumed.NextPage)
+	}
+}
+
+func TestRateLimitedOnFirstPage(t *testing.T) {
+	srv := myipmstest.NewServer(myipmstest.Options{})
+	defer srv.Close()
+	srv.SetRateLimited(true)
+	c := scrapeConfig(t, srv)
+	client, filter, checkpoint := startScrape(t, c)
+
+	result, err := runScrape(context.Background(), client, c, filter, checkpoint, nil)
+	if !errors.Is(err, myipms.ErrRateLimited) {
+		t.Fatalf("runScrape error = %v, want ErrRateLimited", err)
+	}
+	if got := checkpoint.resumeHint(result.NextPage); got != "-start 1" {
+		t.Errorf("resume hint = %q, want -start 1 since -resume has no checkpoint", got)
+	}
+}
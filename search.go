@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// searchResult is one ranked option in the output of the search command
+type searchResult struct {
+	Name  string  `json:"name"`
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// runSearchCommand implements the search command: it ranks the options of a
+// kind against a query and prints them as JSON or TSV
+func runSearchCommand(args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	fs.Usage = showSearchHelp
+
+	format := fs.String("format", "tsv", "Output format (json, tsv)")
+	limit := fs.Int("limit", 10, "Max results (0 = all)")
+	minScore := fs.Float64("min-score", 0.4, "Lowest score to include (0-1)")
+	optionsFile := fs.String("options-file", "", "Option tables written by update-options")
+	rest := parseInterspersed(fs, args)
+
+	if len(rest) < 2 {
+		showSearchHelp()
+		os.Exit(1)
+	}
+	k, ok := optionKinds[strings.ToLower(rest[0])]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown kind '%s' (expected countries, owners, hosts or dns)\n", rest[0])
+		os.Exit(1)
+	}
+	if *format != "json" && *format != "tsv" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format '%s' (expected json or tsv)\n", *format)
+		os.Exit(1)
+	}
+	if *limit < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -limit must be >= 0")
+		os.Exit(1)
+	}
+	if _, err := loadOptionsFile(*optionsFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	table := optionTable(k.kind)
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+
+	results := []searchResult{}
+	for _, m := range rankMatches(strings.Join(rest[1:], " "), names, *minScore, *limit) {
+		score := math.Round(m.Score*1000) / 1000
+		results = append(results, searchResult{Name: m.Name, ID: table[m.Name], Score: score})
+	}
+
+	if err := writeSearchResults(*format, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeSearchResults prints the results to stdout as a JSON array or as TSV
+// with a header row
+func writeSearchResults(format string, results []searchResult) error {
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	w.Comma = '\t'
+	w.Write([]string{"name", "id", "score"})
+	for _, r := range results {
+		w.Write([]string{r.Name, r.ID, strconv.FormatFloat(r.Score, 'f', 3, 64)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// showSearchHelp displays the help message of the search command
+func showSearchHelp() {
+	fmt.Println(`Ranks the filter options of a kind against a query, for autocompletion and for
+picking IDs from scripts.
+
+USAGE:
+  scraper search [OPTIONS] <countries|owners|hosts|dns> <query>
+
+Every option gets a score from 0 to 1, ignoring case: 1 for an equal name,
+above 0.9 when the name starts with the query, above 0.8 when a word of it
+does, above 0.7 when the name contains the query, and otherwise up to 0.7 by
+how few edits turn the query into the name or one of its words. Results are
+printed best first, as TSV with a name, id, score header row, or as a JSON
+array of {"name", "id", "score"} objects. IDs are strings; for countries they
+are the country codes. No match is an empty result, not an error.
+
+EXAMPLES:
+  scraper search owners amazn
+  scraper search -format=json -limit=5 countries germ
+  scraper search dns cloudflare | cut -f2
+
+OPTIONS:
+  -format <format>      Output format: json, tsv (default: tsv)
+  -limit <num>          Max results, 0 = all (default: 10)
+  -min-score <score>    Lowest score to include (default: 0.4)
+  -options-file <file>  Option tables written by update-options (default: none, the built-in tables)`)
+}
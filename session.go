@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// runSessionCommand implements the session command
+func runSessionCommand(args []string) {
+	config, _, rest := parseConfig("session", args, showSessionHelp)
+
+	action := "check"
+	if len(rest) > 0 {
+		action, rest = rest[0], rest[1:]
+	}
+
+	switch {
+	case action == "check" && len(rest) == 0:
+		checkSession(config)
+	case action == "import" && len(rest) == 1:
+		if config.SessionFile == "" {
+			fmt.Println("Error: importing cookies needs a session file (-session)")
+			os.Exit(1)
+		}
+		client := newClient(config)
+		importCookies(client, rest[0])
+		if err := client.SaveSession(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved session to %s\n", config.SessionFile)
+	case action == "clear" && len(rest) == 0:
+		if config.SessionFile == "" {
+			fmt.Println("No session file configured; nothing to clear")
+			return
+		}
+		// The file is removed without loading it, so a corrupt one can be cleared
+		if err := os.Remove(config.SessionFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Cleared session %s; the next run starts from the built-in cookies\n", config.SessionFile)
+	default:
+		showSessionHelp()
+		os.Exit(1)
+	}
+}
+
+// checkSession describes the saved session and tries it against the site with
+// one request. It exits with status 1 unless the site accepts the session.
+func checkSession(config *Config) {
+	if _, err := validateAndResolveFilters(config); err != nil {
+		handleValidationError(err)
+		return
+	}
+
+	client := newClient(config)
+
+	switch savedAt := client.SessionSavedAt(); {
+	case config.SessionFile == "":
+		fmt.Println("Session: disabled (-session=\"\"); using the built-in cookies")
+	case savedAt.IsZero():
+		fmt.Printf("Session: none saved for %s in %s; using the built-in cookies\n", client.BaseURL(), config.SessionFile)
+	default:
+		fmt.Printf("Session: %s, saved %s (%s ago)\n", config.SessionFile,
+			savedAt.Local().Format("2006-01-02 15:04"), time.Since(savedAt).Round(time.Minute))
+	}
+
+	var names []string
+	have := make(map[string]bool)
+	for _, c := range client.Cookies() {
+		names = append(names, c.Name)
+		have[c.Name] = true
+	}
+	fmt.Printf("Cookies: %s\n", strings.Join(names, ", "))
+	for _, name := range myipms.RequiredCookies {
+		if !have[name] {
+			fmt.Printf("Warning: %s is missing; the site will ask for verification\n", name)
+		}
+	}
+
+	ctx, stop := notifyContext()
+	defer stop()
+
+	fmt.Printf("Checking %s... ", client.BaseURL())
+	_, err := client.FetchPage(ctx, myipms.NewQuery(&myipms.Filter{}), 1)
+	saveSession(client)
+
+	switch {
+	case err == nil:
+		fmt.Println("OK, the site accepts the session")
+		return
+	case errors.Is(err, myipms.ErrVerificationRequired):
+		fmt.Println("human verification required")
+		fmt.Println("Solve a captcha with a scrape, or import cookies from a verified browser with: session import <file>")
+	case errors.Is(err, myipms.ErrRateLimited):
+		fmt.Println("rate limited")
+		fmt.Println("The page visit limit applies to your IP address; change IP or use -proxy.")
+	case errors.Is(err, context.Canceled):
+		fmt.Println("interrupted")
+	default:
+		fmt.Printf("failed: %v\n", err)
+	}
+	os.Exit(1)
+}
+
+// showSessionHelp displays the help message of the session command
+func showSessionHelp() {
+	fmt.Println(`Manages the session file that keeps the site's cookies between runs.
+
+USAGE:
+  scraper session [check] [OPTIONS]
+  scraper session import <file> [OPTIONS]
+  scraper session clear [OPTIONS]
+
+ACTIONS:
+  check          Show the saved session and its cookies, then try it with one
+                 request to the site (the default). Exits with status 1 unless
+                 the site accepts it
+  import <file>  Import cookies from a browser that already passed verification
+                 (Netscape cookies.txt or JSON export) into the session file
+  clear          Delete the session file, so the next run starts from the
+                 built-in cookies
+
+OPTIONS:
+  -session <file>    Session file (default: <user config dir>/myipms-scraper/session.json)
+  -base-url <url>    Site the session belongs to (default: https://myip.ms)
+  -proxy <url>       Proxy for the check request
+  -config, -profile  Config file and profile, as for a scrape`)
+}
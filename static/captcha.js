@@ -1,3 +1,13 @@
+// Access token from the URL the server printed; every request must carry it
+const accessToken = document.querySelector('meta[name="access-token"]').content;
+
+// Token of the captcha being shown; it stops working once the captcha is solved.
+// The dashboard gets the token of each new captcha over its event stream.
+let captchaToken = document.querySelector('meta[name="captcha-token"]').content;
+
+// The dashboard runs for the whole scrape; otherwise the page exists for one captcha
+const isDashboard = document.querySelector('meta[name="dashboard"]').content === 'true';
+
 // DOM elements
 const captchaInput = document.getElementById('captcha-input');
 const submitBtn = document.getElementById('submit-btn');
@@ -18,6 +28,10 @@ document.addEventListener('DOMContentLoaded', function() {
 });
 
 // Utility functions
+function captchaURL(path) {
+    return `${path}?token=${encodeURIComponent(accessToken)}&captcha_token=${encodeURIComponent(captchaToken)}`;
+}
+
 function showLoading(show) {
     loadingDiv.style.display = show ? 'block' : 'none';
     submitBtn.disabled = show;
@@ -34,17 +48,18 @@ async function refreshCaptcha() {
         showLoading(true);
         showStatus('Refreshing captcha image...', 'info');
 
-        const response = await fetch('/refresh-captcha', {
+        const response = await fetch(captchaURL('/refresh-captcha'), {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/x-www-form-urlencoded',
+                'X-Captcha-Token': accessToken,
             }
         });
 
         const data = await response.json();
 
         if (data.success) {
-            captchaImage.src = `/captcha-image?t=${Date.now()}`;
+            captchaImage.src = `${captchaURL('/captcha-image')}&t=${Date.now()}`;
 
             captchaImage.onload = function() {
                 showLoading(false);
@@ -80,10 +95,11 @@ async function submitCaptcha() {
         showLoading(true);
         showStatus('Validating captcha...', 'info');
 
-        const response = await fetch('/submit-captcha', {
+        const response = await fetch(captchaURL('/submit-captcha'), {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/x-www-form-urlencoded',
+                'X-Captcha-Token': accessToken,
             },
             body: `captcha=${encodeURIComponent(captchaText)}`
         });
@@ -92,7 +108,10 @@ async function submitCaptcha() {
 
         showLoading(false);
 
-        if (data.success) {
+        if (data.success && isDashboard) {
+            showStatus('✅ Captcha validated successfully! Scraping continues.', 'success');
+            captchaInput.value = '';
+        } else if (data.success) {
             showStatus('✅ Captcha validated successfully! You can close this window. Scraping will continue automatically.', 'success');
             setTimeout(() => {
                 window.close();
@@ -111,3 +130,81 @@ async function submitCaptcha() {
         captchaInput.focus();
     }
 }
+
+// Dashboard
+const stateLabels = {
+    starting: 'Starting...',
+    running: '⏳ Scraping',
+    captcha: '🔒 Waiting for captcha',
+    done: '✅ Scraping complete',
+    interrupted: '⏹️ Interrupted',
+    rate_limited: '🚫 Rate limited',
+    failed: '❌ Failed',
+};
+
+// States the scraper stops in; the event stream ends after sending one
+const finalStates = new Set(['done', 'interrupted', 'rate_limited', 'failed']);
+
+let captchaShown = false;
+let nextRequestAt = 0;
+let startedAt = 0;
+
+function formatDuration(ms) {
+    const seconds = Math.max(0, Math.round(ms / 1000));
+    const h = Math.floor(seconds / 3600);
+    const m = Math.floor((seconds % 3600) / 60);
+    const s = seconds % 60;
+    return h > 0 ? `${h}h ${m}m ${s}s` : m > 0 ? `${m}m ${s}s` : `${s}s`;
+}
+
+function renderProgress(progress) {
+    document.getElementById('state').textContent = stateLabels[progress.state] || progress.state;
+    document.getElementById('stat-page').textContent = progress.page || '-';
+    document.getElementById('stat-total').textContent = progress.total;
+    document.getElementById('stat-duplicates').textContent = progress.duplicates;
+
+    const errors = Object.entries(progress.errors || {}).map(([kind, n]) => `${kind.replace('_', ' ')}: ${n}`);
+    document.getElementById('stat-errors').textContent = errors.length ? errors.join(', ') : 'none';
+
+    const rows = (progress.recent_pages || []).slice().reverse()
+        .map(p => `<tr><td>${p.page}</td><td>${p.domains}</td></tr>`);
+    document.getElementById('recent-pages').innerHTML = rows.join('');
+
+    nextRequestAt = Date.now() + progress.next_request_ms;
+    startedAt = Date.parse(progress.started_at);
+
+    if (progress.captcha_pending && (!captchaShown || progress.captcha_token !== captchaToken)) {
+        captchaShown = true;
+        captchaToken = progress.captcha_token;
+        document.getElementById('captcha-container').hidden = false;
+        statusDiv.innerHTML = '';
+        captchaImage.src = `${captchaURL('/captcha-image')}&t=${Date.now()}`;
+        captchaInput.focus();
+    } else if (!progress.captcha_pending && captchaShown) {
+        captchaShown = false;
+        document.getElementById('captcha-container').hidden = true;
+    }
+}
+
+function tick() {
+    const next = nextRequestAt - Date.now();
+    document.getElementById('stat-next').textContent = next > 0 ? formatDuration(next) : 'now';
+    if (startedAt) {
+        document.getElementById('stat-elapsed').textContent = formatDuration(Date.now() - startedAt);
+    }
+}
+
+if (isDashboard) {
+    const events = new EventSource(`/events?token=${encodeURIComponent(accessToken)}`);
+    events.onmessage = (e) => {
+        const progress = JSON.parse(e.data);
+        renderProgress(progress);
+        if (finalStates.has(progress.state)) {
+            events.close();
+        }
+    };
+    events.onerror = () => {
+        document.getElementById('state').textContent = '⚠️ Disconnected from the scraper';
+    };
+    setInterval(tick, 250);
+}
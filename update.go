@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+// defaultOptionsPath returns the options file update-options writes when
+// -options-file is not given
+func defaultOptionsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "myipms-options.json"
+	}
+	return filepath.Join(dir, "myipms-scraper", "options.json")
+}
+
+// loadOptionsFile replaces the built-in option tables with those of the
+// options file written by update-options. Since update-options scrapes
+// listings not confirmed against the site, the file is only used when named
+// with -options-file; an empty path keeps the built-in tables.
+func loadOptionsFile(path string) (*myipms.Catalog, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	catalog, err := myipms.LoadCatalog(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading options file: %w", err)
+	}
+
+	myipms.UseCatalog(catalog)
+	return catalog, nil
+}
+
+// useOptionsFile loads the options file of config, exiting on error. It
+// returns nil when there is none.
+func useOptionsFile(config *Config) *myipms.Catalog {
+	catalog, err := loadOptionsFile(config.OptionsFile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	return catalog
+}
+
+// runUpdateOptions implements the experimental update-options command: it
+// scrapes the site's owner, host, DNS and country listings into the options file
+func runUpdateOptions(args []string) {
+	config, _, rest := parseConfig("update-options", args, showUpdateOptionsHelp)
+	if len(rest) > 0 {
+		fmt.Printf("Error: unexpected argument '%s'\n", rest[0])
+		os.Exit(1)
+	}
+	if config.OptionsFile == "" {
+		config.OptionsFile = defaultOptionsPath()
+	}
+
+	// Only the shared options apply; no filters are set
+	if _, err := validateAndResolveFilters(config); err != nil {
+		handleValidationError(err)
+		return
+	}
+
+	// Kinds that fail keep the tables they had in the existing file
+	catalog, err := myipms.LoadCatalog(config.OptionsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		catalog, err = myipms.NewCatalog(), nil
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := notifyContext()
+	defer stop()
+
+	client := newClient(config)
+
+	fmt.Println("Warning: update-options is experimental; the listings it scrapes have not been confirmed against myip.ms")
+	fmt.Printf("Updating filter options from %s into %s\n", client.BaseURL(), config.OptionsFile)
+
+	var empty []string
+	for _, kind := range myipms.CatalogKinds {
+		entries, err := fetchCatalog(ctx, client, config, kind)
+		saveSession(client)
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("\nInterrupted. Options updated so far are saved.")
+				os.Exit(1)
+			}
+			fmt.Printf("Error: %s: %v\n", kind, err)
+			if errors.Is(err, myipms.ErrRateLimited) {
+				fmt.Println("IP address has been rate limited. Change IP or use a proxy and run update-options again.")
+			}
+			os.Exit(1)
+		}
+
+		// The listing paths are not confirmed against the site, so finding
+		// nothing more likely means a wrong path or layout than an empty table
+		if len(entries) == 0 {
+			fmt.Printf("Error: no %s found; the site's %s listing may have moved or changed. Keeping the previous table\n", kind, kind)
+			empty = append(empty, kind)
+			continue
+		}
+
+		catalog.Reset(kind)
+		catalog.Add(kind, entries)
+		catalog.UpdatedAt = time.Now().UTC()
+		if err := catalog.Save(config.OptionsFile); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved %d %s\n", len(entries), kind)
+	}
+
+	fmt.Printf("Options updated: %d countries, %d owners, %d hosts, %d DNS records\n",
+		len(catalog.Countries), len(catalog.Owners), len(catalog.Hosts), len(catalog.DNS))
+	fmt.Printf("Check them with 'list -options-file=%s', then use them with -options-file=%s\n", config.OptionsFile, config.OptionsFile)
+	if len(empty) > 0 {
+		fmt.Printf("Error: no entries found for %s\n", strings.Join(empty, ", "))
+		os.Exit(1)
+	}
+}
+
+// fetchCatalog fetches every page of the listing of kind, up to -pages pages,
+// solving captchas as they come up. It returns the entries, name to ID.
+func fetchCatalog(ctx context.Context, client *myipms.Client, c *Config, kind string) (map[string]string, error) {
+	all := make(map[string]string)
+
+	for page := c.StartPage; c.MaxPages == 0 || page < c.StartPage+c.MaxPages; {
+		entries, err := client.FetchCatalogPage(ctx, kind, page)
+		if errors.Is(err, myipms.ErrVerificationRequired) {
+			fmt.Printf("Error: %v\n", err)
+			if err := solveCaptcha(ctx, client, c, nil); err != nil {
+				return nil, fmt.Errorf("failed to solve captcha: %v", err)
+			}
+			saveSession(client)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for name, id := range entries {
+			if _, ok := all[name]; !ok {
+				added++
+			}
+			all[name] = id
+		}
+		// Pages past the end are empty or repeat the last one
+		if added == 0 {
+			break
+		}
+
+		fmt.Printf("%s page %d: %d entries (Total: %d)\n", kind, page, len(entries), len(all))
+		page++
+	}
+
+	return all, nil
+}
+
+// showUpdateOptionsHelp displays the help message of the update-options command
+func showUpdateOptionsHelp() {
+	fmt.Println(`Refreshes the owner, host, DNS and country tables from myip.ms's own listings.
+
+EXPERIMENTAL: the listing pages it scrapes and their layout have not been
+confirmed against myip.ms, so the tables may be incomplete or wrong. Check them
+with the list command before relying on them.
+
+USAGE:
+  scraper update-options [OPTIONS]
+
+The tables are written to the options file. Later runs keep using the built-in
+tables unless given the file with -options-file. Each table is saved as soon as
+its listing is complete, so an interrupted update keeps the tables it already
+finished. A listing that yields no entries keeps its previous table and makes
+the command exit with status 1.
+
+OPTIONS:
+  -options-file <file>  Options file to write (default: <user config dir>/myipms-scraper/options.json)
+  -pages <num>          Max pages per listing (0 = unlimited, default: unlimited)
+  -start <num>          Starting page of each listing (default: 1)
+
+Session, captcha, proxy, -rate, -base-url and -config options work as for a scrape.`)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ayanrajpoot10/myipms-scraper/myipms"
+)
+
+func TestLoadOptionsFile(t *testing.T) {
+	owners := myipms.Owners
+	defer func() { myipms.Owners = owners }()
+
+	// Without -options-file the built-in tables stay, even if update-options wrote a file
+	if catalog, err := loadOptionsFile(""); catalog != nil || err != nil {
+		t.Fatalf("loadOptionsFile(\"\") = %v, %v; want nothing loaded", catalog, err)
+	}
+	if len(myipms.Owners) != len(owners) {
+		t.Fatalf("built-in owners changed without an options file")
+	}
+
+	dir := t.TempDir()
+	if _, err := loadOptionsFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loadOptionsFile() of a missing file succeeded, want an error")
+	}
+
+	path := filepath.Join(dir, "options.json")
+	saved := myipms.NewCatalog()
+	saved.Add(myipms.CatalogOwners, map[string]string{"Example Hosting Company, LLC": "900001"})
+	if err := saved.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadOptionsFile(path); err != nil {
+		t.Fatalf("loadOptionsFile: %v", err)
+	}
+	if id, ok := myipms.Owners["Example Hosting Company, LLC"]; !ok || id != 900001 {
+		t.Errorf("owner from the options file = %d, %v; want 900001", id, ok)
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"math"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // levenshteinDistance calculates the Levenshtein distance between two strings.
@@ -93,6 +96,78 @@ func findBestMatches(input string, options []string, maxResults int) []string {
 	return results
 }
 
+// scoredMatch is an option ranked against a query
+type scoredMatch struct {
+	Name  string
+	Score float64
+}
+
+// matchScore rates how well option matches query, ignoring case, from 0 (no
+// resemblance) to 1 (equal). Prefix, word-prefix and substring matches score
+// above 0.7, longer ones higher; anything else scores up to 0.7 by edit
+// distance to the whole option or its closest word.
+func matchScore(query, option string) float64 {
+	q := strings.ToLower(strings.TrimSpace(query))
+	o := strings.ToLower(option)
+	if q == "" || o == "" {
+		return 0
+	}
+	if q == o {
+		return 1
+	}
+
+	coverage := float64(len(q)) / float64(len(o))
+	switch i := strings.Index(o, q); {
+	case i == 0:
+		return 0.9 + 0.09*coverage
+	case i > 0 && isWordStart(o, i):
+		return 0.8 + 0.09*coverage
+	case i > 0:
+		return 0.7 + 0.09*coverage
+	}
+
+	similarity := func(a, b string) float64 {
+		return 1 - float64(levenshteinDistance(a, b))/float64(max(len(a), len(b)))
+	}
+	best := similarity(q, o)
+	words := strings.FieldsFunc(o, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		best = math.Max(best, similarity(q, w))
+	}
+	return 0.7 * best
+}
+
+// isWordStart reports whether s[i] starts a word
+func isWordStart(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// rankMatches scores every option against query and returns those scoring at
+// least minScore, best first, at most limit of them (0 = all)
+func rankMatches(query string, options []string, minScore float64, limit int) []scoredMatch {
+	var matches []scoredMatch
+	for _, option := range options {
+		if score := matchScore(query, option); score >= minScore && score > 0 {
+			matches = append(matches, scoredMatch{option, score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
 // max returns the larger of two ints.
 func max(a, b int) int {
 	if a > b {
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchScoreBands(t *testing.T) {
+	tests := []struct {
+		query, option string
+		min, max      float64 // min is exclusive unless it equals max
+	}{
+		{query: "Amazon.com, Inc", option: "Amazon.com, Inc", min: 1, max: 1},
+		{query: " amazon.COM, inc ", option: "Amazon.com, Inc", min: 1, max: 1},
+		{query: "amazon", option: "Amazon.com, Inc", min: 0.9, max: 1},
+		{query: "amazon.com, in", option: "Amazon.com, Inc", min: 0.9, max: 1},
+		{query: "inc", option: "Amazon.com, Inc", min: 0.8, max: 0.9},
+		{query: "online", option: "Hetzner Online GmbH", min: 0.8, max: 0.9},
+		{query: "zon", option: "Amazon.com, Inc", min: 0.7, max: 0.8},
+		{query: "flare", option: "Cloudflare, Inc", min: 0.7, max: 0.8},
+		{query: "amazno", option: "Amazon.com, Inc", min: 0, max: 0.7},
+		{query: "hetzenr", option: "Hetzner Online GmbH", min: 0, max: 0.7},
+		{query: "", option: "Amazon.com, Inc", min: 0, max: 0},
+	}
+
+	for _, tt := range tests {
+		got := matchScore(tt.query, tt.option)
+		inBand := got > tt.min && got <= tt.max
+		if tt.min == tt.max {
+			inBand = got == tt.max
+		}
+		if !inBand {
+			t.Errorf("matchScore(%q, %q) = %.3f, want in (%.1f, %.1f]", tt.query, tt.option, got, tt.min, tt.max)
+		}
+	}
+
+	// Within a band, a query covering more of the option scores higher
+	if matchScore("amazon.com", "Amazon.com, Inc") <= matchScore("amazon", "Amazon.com, Inc") {
+		t.Error("a longer prefix does not score above a shorter one")
+	}
+}
+
+func TestRankMatches(t *testing.T) {
+	options := []string{"Cloudflare, Inc", "Amazon.com, Inc", "Google LLC", "Amazon Data Services", "Inc Networks"}
+
+	var got []string
+	for _, m := range rankMatches("inc", options, 0.7, 0) {
+		got = append(got, m.Name)
+	}
+	// The prefix first, then the word prefixes, which tie on coverage, by name
+	want := []string{"Inc Networks", "Amazon.com, Inc", "Cloudflare, Inc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rankMatches(inc) = %q, want %q", got, want)
+	}
+
+	if got := rankMatches("amazon", options, 0, 1); len(got) != 1 || got[0].Name != "Amazon.com, Inc" {
+		t.Errorf("rankMatches(amazon, limit 1) = %+v, want only the prefix covering more of its option", got)
+	}
+	if got := rankMatches("zzzz", options, 0.5, 0); len(got) != 0 {
+		t.Errorf("rankMatches(zzzz) = %+v, want none above the minimum", got)
+	}
+}